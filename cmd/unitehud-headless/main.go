@@ -0,0 +1,178 @@
+// unitehud-headless runs UniteHUD detection and the UniteHUD server without a window or overlay.
+//
+//	unitehud-headless -profile broadcaster -source match.mp4 -addr 0.0.0.0:17069 -output results
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/detect"
+	"github.com/pidgy/unitehud/history"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/server"
+	"github.com/pidgy/unitehud/state"
+	"github.com/pidgy/unitehud/stats"
+	"github.com/pidgy/unitehud/team"
+	"github.com/pidgy/unitehud/video"
+)
+
+var (
+	profile  = flag.String("profile", config.ProfilePlayer, "configuration profile, player or broadcaster")
+	source   = flag.String("source", "", "video capture device index, video file, directory of PNG frames, or window name (default configured source)")
+	mode     = flag.String("mode", "", "video capture device frame size, WIDTHxHEIGHT (default configured mode)")
+	realtime = flag.Bool("realtime", false, "play video files at their recorded frame rate instead of as fast as possible (default configured pace)")
+	addr     = flag.String("addr", "", "server address (default configured address, or "+server.Address+")")
+	token    = flag.Bool("token", false, "print the server control token")
+	output   = flag.String("output", ".", "directory to store match history, exports and event journals")
+)
+
+func main() {
+	flag.Parse()
+
+	notify.Output = os.Stdout
+
+	notify.System("Initializing...")
+
+	err := config.Load(*profile)
+	if err != nil {
+		notify.Error("Failed to load configuration (%v)", err)
+		os.Exit(1)
+	}
+
+	// Flags override the configuration for this run only, and are not saved.
+	sourced(*source)
+
+	err = os.MkdirAll(*output, 0755)
+	if err != nil {
+		notify.Error("Failed to create output directory \"%s\" (%v)", *output, err)
+		os.Exit(1)
+	}
+
+	history.File = filepath.Join(*output, history.File)
+	history.Exports = filepath.Join(*output, history.Exports)
+	state.Journal = filepath.Join(*output, state.Journal)
+
+	err = history.Open()
+	if err != nil {
+		notify.Error("Failed to open match history (%v)", err)
+	}
+
+	err = video.Open()
+	if err != nil {
+		notify.Error("Failed to open video source (%v)", err)
+		os.Exit(1)
+	}
+
+	if config.Current.ServerAddress != "" {
+		server.Address = config.Current.ServerAddress
+	}
+	if *addr != "" {
+		server.Address = *addr
+	}
+
+	err = server.Listen()
+	if err != nil {
+		notify.Error("Failed to start UniteHUD server (%v)", err)
+		os.Exit(1)
+	}
+
+	notify.System("Server address: \"%s\"", server.Address)
+	if *token {
+		notify.System("Server control token: \"%s\"", config.Current.ServerToken)
+	}
+	notify.System("Profile: %s", config.Current.Profile)
+	notify.System("Output: %s", *output)
+
+	detect.Start()
+
+	start()
+
+	go func() {
+		for action := range server.Actions {
+			switch action {
+			case server.Start:
+				start()
+			case server.Stop:
+				stop()
+			}
+		}
+	}()
+
+	sigq := make(chan os.Signal, 1)
+	signal.Notify(sigq, os.Interrupt, syscall.SIGTERM)
+	<-sigq
+
+	stop()
+	video.Close()
+
+	notify.System("Closed")
+}
+
+// sourced applies the -source, -mode and -realtime flags to the current configuration. The mode and
+// pace also apply to the configured source when -source is not set.
+func sourced(s string) {
+	if *mode != "" {
+		config.Current.VideoCaptureMode = *mode
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "realtime" {
+			config.Current.VideoFileRealTime = *realtime
+		}
+	})
+
+	if s == "" {
+		return
+	}
+
+	config.Current.VideoFile = ""
+	config.Current.VideoCaptureDevice = config.NoVideoCaptureDevice
+
+	d, err := strconv.Atoi(s)
+	if err == nil {
+		config.Current.VideoCaptureDevice = d
+		return
+	}
+
+	_, err = os.Stat(s)
+	if err == nil {
+		config.Current.VideoFile = s
+		return
+	}
+
+	config.Current.Window = s
+}
+
+func start() {
+	notify.Announce("Starting...")
+
+	server.Clear()
+	team.Clear()
+	stats.Clear()
+	state.Clear()
+
+	detect.Resume()
+
+	server.SetStarted()
+
+	notify.Announce("Started")
+}
+
+func stop() {
+	notify.Denounce("Stopping...")
+
+	detect.Pause()
+
+	server.Clear()
+	team.Clear()
+
+	server.SetStopped()
+
+	notify.Denounce("Stopped")
+}
@@ -0,0 +1,90 @@
+// unitehud-regression replays a corpus of labelled screenshots through the UniteHUD matchers, and exits
+// with a non-zero status when any label falls below the minimum accuracy.
+//
+//	unitehud-regression -corpus regression/testdata/corpus -assets assets -min 100
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/regression"
+)
+
+var (
+	corpus  = flag.String("corpus", "corpus", "directory containing "+regression.Manifest)
+	assets  = flag.String("assets", "", "assets directory, defaults to the directory next to the executable")
+	minimum = flag.Float64("min", 100, "minimum accuracy percentage of every label")
+	verbose = flag.Bool("v", false, "print every case and the notify feed")
+)
+
+func main() {
+	flag.Parse()
+
+	if *verbose {
+		notify.Output = os.Stderr
+	}
+
+	config.AssetsDirectory = *assets
+
+	r, err := regression.Run(*corpus)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to run regression corpus \"%s\" (%v)\n", *corpus, err)
+		os.Exit(2)
+	}
+
+	for _, res := range r.Results {
+		if *verbose || !res.Passed() {
+			fmt.Println(res)
+		}
+	}
+
+	failed := report(os.Stdout, r)
+
+	fmt.Printf("\n%d/%d cases passed\n", r.Passed, len(r.Results))
+
+	if failed > 0 {
+		fmt.Printf("%d label%s below %.1f%% accuracy\n", failed, s(failed), *minimum)
+		os.Exit(1)
+	}
+}
+
+// report writes the accuracy of every label, and returns the number of labels below the minimum.
+func report(w io.Writer, r *regression.Report) int {
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	table.SetBorder(false)
+	table.SetHeader([]string{"Label", "Passed", "Total", "Accuracy"})
+
+	failed := 0
+
+	for _, a := range r.Accuracy {
+		if a.Percent() < *minimum {
+			failed++
+		}
+
+		table.Append([]string{
+			a.Label,
+			fmt.Sprintf("%d", a.Passed),
+			fmt.Sprintf("%d", a.Total),
+			fmt.Sprintf("%.1f%%", a.Percent()),
+		})
+	}
+
+	table.Render()
+
+	return failed
+}
+
+func s(size int) string {
+	if size == 1 {
+		return ""
+	}
+	return "s"
+}
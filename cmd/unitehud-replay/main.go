@@ -0,0 +1,97 @@
+// unitehud-replay runs the UniteHUD server and replays a recorded event journal through it, so overlays
+// can be rehearsed without a match or video source. The latest journal is replayed by default.
+//
+//	unitehud-replay -profile broadcaster -journal journal/20230501-200412.000.events -speed 4
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/replay"
+	"github.com/pidgy/unitehud/server"
+	"github.com/pidgy/unitehud/state"
+)
+
+var (
+	profile = flag.String("profile", config.ProfileBroadcaster, "configuration profile, player or broadcaster")
+	journal = flag.String("journal", "", "event journal to replay (default latest journal)")
+	speed   = flag.Float64("speed", 1, "multiple of the recorded pace, or 0 to replay as fast as possible")
+	addr    = flag.String("addr", "", "server address (default configured address, or "+server.Address+")")
+	hold    = flag.Bool("hold", true, "keep serving the final state of the match after the replay")
+)
+
+func main() {
+	flag.Parse()
+
+	notify.Output = os.Stdout
+
+	err := config.Load(*profile)
+	if err != nil {
+		notify.Error("Failed to load configuration (%v)", err)
+		os.Exit(1)
+	}
+
+	file := *journal
+	if file == "" {
+		files, err := state.Journals()
+		if err != nil || len(files) == 0 {
+			notify.Error("Failed to find an event journal in \"%s\" (%v)", state.Journal, err)
+			os.Exit(1)
+		}
+		file = files[len(files)-1]
+	}
+
+	// Replayed events are not journaled again.
+	state.Journal = ""
+
+	if config.Current.ServerAddress != "" {
+		server.Address = config.Current.ServerAddress
+	}
+	if *addr != "" {
+		server.Address = *addr
+	}
+
+	err = server.Listen()
+	if err != nil {
+		notify.Error("Failed to start UniteHUD server (%v)", err)
+		os.Exit(1)
+	}
+
+	notify.System("Server address: \"%s\"", server.Address)
+
+	server.SetStarted()
+
+	stop := make(chan bool)
+	done := make(chan bool)
+
+	go func() {
+		defer close(done)
+
+		err := replay.Run(file, *speed, stop)
+		if err != nil {
+			notify.Error("Failed to replay \"%s\" (%v)", file, err)
+		}
+	}()
+
+	sigq := make(chan os.Signal, 1)
+	signal.Notify(sigq, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-sigq:
+		close(stop)
+		<-done
+	case <-done:
+		if *hold {
+			<-sigq
+		}
+	}
+
+	server.SetStopped()
+
+	notify.System("Closed")
+}
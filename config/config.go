@@ -1,12 +1,13 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"image"
 	"image/color"
 	"os"
-	"path"
 	"path/filepath"
 	"reflect"
 	"strconv"
@@ -36,11 +37,14 @@ const (
 	PlatformSwitch     = "switch"
 	PlatformMobile     = "mobile"
 	PlatformBluestacks = "bluestacks"
+
+	MapTheiaSkyRuins = "theia_sky_ruins"
 )
 
 type Config struct {
 	Window                   string
 	VideoCaptureDevice       int
+	VideoCaptureMode         string // Frame size of the video capture device, WIDTHxHEIGHT, defaults to the main display resolution.
 	LostWindow               string `json:"-"`
 	Record                   bool   `json:"-"` // Record all matched images and logs.
 	Energy                   image.Rectangle
@@ -48,6 +52,7 @@ type Config struct {
 	Time                     image.Rectangle
 	Objectives               image.Rectangle
 	KOs                      image.Rectangle
+	MiniMap                  image.Rectangle
 	filenames                map[string]map[string][]filter.Filter      `json:"-"`
 	templates                map[string]map[string][]*template.Template `json:"-"`
 	Scale                    float64
@@ -57,6 +62,12 @@ type Config struct {
 	DisableBrowserFormatting bool
 	Platform                 string
 	HUDOverlay               bool
+	ServerAddress            string // Address the server listens on, defaults to 127.0.0.1:17069.
+	ServerToken              string // Bearer token required by server control endpoints.
+	VideoFile                string // Video file, or directory of PNG frames, used in place of a capture source.
+	VideoFileRealTime        bool   // Play VideoFile at its recorded frame rate instead of as fast as possible.
+	Map                      string // Rules of the map, assets/maps/{Map}.json.
+	rules                    *Rules `json:"-"`
 
 	Theme Theme
 
@@ -89,14 +100,21 @@ type Theme struct {
 
 var Current Config
 
+// AssetsDirectory overrides the assets directory, which is found next to the executable by default.
+var AssetsDirectory = ""
+
 func (c *Config) Assets() string {
+	if AssetsDirectory != "" {
+		return AssetsDirectory
+	}
+
 	e, err := os.Executable()
 	if err != nil {
-		notify.Error("Failed to find profile directory (%v)", err)
+		notify.Error("Failed to find assets directory (%v)", err)
 		return ""
 	}
 
-	return fmt.Sprintf(`%s\assets`, filepath.Dir(e))
+	return filepath.Join(filepath.Dir(e), "assets")
 }
 
 func (c *Config) Eq(c2 *Config) bool {
@@ -105,6 +123,7 @@ func (c *Config) Eq(c2 *Config) bool {
 			func() {},
 			map[string]map[string][]filter.Filter{},
 			map[string]map[string][]*template.Template{},
+			&Rules{},
 		),
 	)
 }
@@ -114,13 +133,7 @@ func (c *Config) File() string {
 }
 
 func (c *Config) ProfileAssets() string {
-	e, err := os.Executable()
-	if err != nil {
-		notify.Error("Failed to find profile directory (%v)", err)
-		return ""
-	}
-
-	return path.Join(filepath.Dir(e), "assets", "profiles", c.Profile, c.Platform)
+	return filepath.Join(c.Assets(), "profiles", c.Profile, c.Platform)
 }
 
 func (c *Config) Reload() {
@@ -186,6 +199,7 @@ func (c *Config) SetDefaultAreas() {
 	c.Scores = scores
 	c.Time = time
 	c.setKOArea()
+	c.setMiniMapArea()
 	c.setObjectiveArea()
 }
 
@@ -203,6 +217,17 @@ func (c *Config) SetDefaultTheme() {
 	}
 }
 
+// SetPlatform reloads the profile templates for platform p.
+func (c *Config) SetPlatform(p string) {
+	c.Platform = p
+
+	if c.load != nil {
+		c.load()
+	}
+
+	validate()
+}
+
 func (c *Config) SetProfile(p string) {
 	switch p {
 	case ProfileBroadcaster:
@@ -394,6 +419,15 @@ func (c *Config) setKOArea() {
 	}
 }
 
+func (c *Config) setMiniMapArea() {
+	switch c.Profile {
+	case ProfileBroadcaster:
+		c.MiniMap = image.Rect(0, 0, 400, 220)
+	case ProfilePlayer:
+		c.MiniMap = image.Rect(0, 0, 400, 220)
+	}
+}
+
 func (c *Config) setObjectiveArea() {
 	switch c.Profile {
 	case ProfileBroadcaster:
@@ -473,25 +507,24 @@ func Load(profile string) error {
 		Current.VideoCaptureDevice = NoVideoCaptureDevice
 	}
 
+	if Current.MiniMap.Empty() {
+		Current.setMiniMapArea()
+	}
+
 	if Current.Platform == "" {
 		Current.Platform = "Switch"
 	}
 
-	return Current.Save()
-}
-
-func TemplatesFirstRound(t1 []*template.Template) []*template.Template {
-	t2 := []*template.Template{}
-	for _, t := range t1 {
-		if t.Value == 0 {
-			continue
-		}
-		t2 = append(t2, t)
+	if Current.ServerToken == "" {
+		Current.ServerToken = token()
 	}
-	return t2
+
+	return Current.Save()
 }
 
 func loadProfileAssetsBroadcaster() {
+	Current.loadRules()
+
 	Current.filenames = map[string]map[string][]filter.Filter{
 		"goals": {
 			team.Game.Name: {
@@ -503,18 +536,7 @@ func loadProfileAssetsBroadcaster() {
 		},
 		"killed": {},
 		"secure": {
-			team.Game.Name: {
-				filter.New(team.Game, Current.ProfileAssets()+"/game/rayquaza_ally.png", state.RayquazaSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/rayquaza_enemy.png", state.RayquazaSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regice_ally.png", state.RegiceSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regice_enemy.png", state.RegiceSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regirock_ally.png", state.RegirockSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regirock_enemy.png", state.RegirockSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/registeel_ally.png", state.RegisteelSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/registeel_enemy.png", state.RegisteelSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regieleki_ally.png", state.RegielekiSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regieleki_enemy.png", state.RegielekiSecureOrange.Int(), false),
-			},
+			team.Game.Name: Current.secureFiles(),
 		},
 		"ko": {
 			team.Game.Name: {
@@ -550,6 +572,8 @@ func loadProfileAssetsBroadcaster() {
 }
 
 func loadProfileAssetsPlayer() {
+	Current.loadRules()
+
 	Current.filenames = map[string]map[string][]filter.Filter{
 		"goals": {
 			team.Game.Name: {
@@ -567,18 +591,7 @@ func loadProfileAssetsPlayer() {
 			},
 		},
 		"secure": {
-			team.Game.Name: {
-				filter.New(team.Game, Current.ProfileAssets()+"/game/rayquaza_ally.png", state.RayquazaSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/rayquaza_enemy.png", state.RayquazaSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regieleki_ally.png", state.RegielekiSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regieleki_enemy.png", state.RegielekiSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regice_ally.png", state.RegiceSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regice_enemy.png", state.RegiceSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regirock_ally.png", state.RegirockSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/regirock_enemy.png", state.RegirockSecureOrange.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/registeel_ally.png", state.RegisteelSecurePurple.Int(), false),
-				filter.New(team.Game, Current.ProfileAssets()+"/game/registeel_enemy.png", state.RegisteelSecureOrange.Int(), false),
-			},
+			team.Game.Name: Current.secureFiles(),
 		},
 		"ko": {
 			team.Game.Name: {
@@ -660,6 +673,17 @@ func open() bool {
 	return true
 }
 
+func token() string {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		notify.Error("Failed to generate server token (%v)", err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
 func validate() {
 	Current.templates = map[string]map[string][]*template.Template{
 		"goals": {
@@ -0,0 +1,15 @@
+package config
+
+// Goal is a goal zone of a map, positioned as a fraction of the minimap size with the purple base on the
+// left side of the minimap. Orange goal zones mirror purple goal zones horizontally.
+type Goal struct {
+	Tier     int
+	Lane     string
+	X, Y     float64
+	Capacity int // Points required to destroy the goal zone, 0 for goal zones that cannot be destroyed.
+}
+
+// Goals returns the goal zones of the current map.
+func (c *Config) Goals() []Goal {
+	return c.Rules().Goals
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pidgy/unitehud/filter"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/state"
+	"github.com/pidgy/unitehud/team"
+)
+
+const (
+	LaneTop    = "top"
+	LaneBottom = "bottom"
+	LaneMiddle = "middle"
+)
+
+// Rules are the match rules of a map, loaded from assets/maps/{Map}.json.
+type Rules struct {
+	Name         string
+	Match        int // Match length in seconds.
+	FinalStretch int // Seconds remaining when the final stretch starts.
+	Multiplier   int // Score multiplier of the final stretch.
+	Objectives   []Objective
+	Goals        []Goal
+	Spawns       []Spawn
+}
+
+// Objective is a securable objective, matched from the {Name}_ally.png and {Name}_enemy.png secure templates.
+// Top lane objectives fill a fixed number of slots, bottom lane objectives are kept in the order they are
+// secured, and the middle objective is secured once.
+type Objective struct {
+	Name  string
+	Lane  string
+	Slots int    // Slots of a top lane objective.
+	Event string // Secure event reported for the objective, defaults to Name.
+}
+
+// Spawn is an objective spawn schedule of a map, in seconds remaining on the match clock.
+type Spawn struct {
+	Name    string
+	Secures []string // Objectives that end the spawn when secured.
+	First   int
+	Respawn int // Seconds between a secure and the next spawn, 0 for objectives that spawn once.
+	Until   int // Last possible spawn.
+}
+
+// TheiaSkyRuins are the rules used when the rules of the configured map cannot be loaded.
+var TheiaSkyRuins = &Rules{
+	Name:         "Theia Sky Ruins",
+	Match:        600,
+	FinalStretch: 120,
+	Multiplier:   2,
+	Objectives: []Objective{
+		{Name: "regieleki", Lane: LaneTop, Slots: 3},
+		{Name: "regice", Lane: LaneBottom},
+		{Name: "regirock", Lane: LaneBottom},
+		{Name: "registeel", Lane: LaneBottom},
+		{Name: "rayquaza", Lane: LaneMiddle},
+	},
+	Goals: []Goal{
+		{Tier: 1, Lane: LaneTop, X: .36, Y: .16, Capacity: 120},
+		{Tier: 1, Lane: LaneBottom, X: .36, Y: .84, Capacity: 120},
+		{Tier: 2, Lane: LaneTop, X: .2, Y: .22, Capacity: 200},
+		{Tier: 2, Lane: LaneBottom, X: .2, Y: .78, Capacity: 200},
+		{Tier: 3, Lane: LaneMiddle, X: .07, Y: .5},
+	},
+	Spawns: []Spawn{
+		{Name: "regieleki", Secures: []string{"regieleki"}, First: 420, Respawn: 120, Until: 120},
+		{Name: "bottom", Secures: []string{"regice", "regirock", "registeel"}, First: 420, Respawn: 120, Until: 120},
+		{Name: "rayquaza", Secures: []string{"rayquaza"}, First: 120},
+	},
+}
+
+// Objective returns the objective with a name.
+func (r *Rules) Objective(name string) (Objective, bool) {
+	for _, o := range r.Objectives {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return Objective{}, false
+}
+
+// Secured returns the objective and team of a secure event.
+func (r *Rules) Secured(e state.EventType) (Objective, *team.Team, bool) {
+	for _, o := range r.Objectives {
+		for _, t := range []*team.Team{team.Purple, team.Orange} {
+			if o.Secured(t) == e {
+				return o, t, true
+			}
+		}
+	}
+	return Objective{}, nil, false
+}
+
+// Top returns the top lane objective.
+func (r *Rules) Top() Objective {
+	for _, o := range r.Objectives {
+		if o.Lane == LaneTop {
+			return o
+		}
+	}
+	return Objective{Lane: LaneTop}
+}
+
+// Middle returns the middle objective.
+func (r *Rules) Middle() Objective {
+	for _, o := range r.Objectives {
+		if o.Lane == LaneMiddle {
+			return o
+		}
+	}
+	return Objective{Lane: LaneMiddle}
+}
+
+// Secured returns the secure event of the objective for a team.
+func (o Objective) Secured(t *team.Team) state.EventType {
+	if o.Event == "" {
+		return state.SecuredBy(o.Name, t.Name)
+	}
+	return state.SecuredBy(o.Event, t.Name)
+}
+
+// Maps returns the directory of map rules.
+func (c *Config) Maps() string {
+	return filepath.Join(c.Assets(), "maps")
+}
+
+// Rules returns the rules of the configured map.
+func (c *Config) Rules() *Rules {
+	if c.rules == nil {
+		return TheiaSkyRuins
+	}
+	return c.rules
+}
+
+// Spawns returns the objective spawns of the current map.
+func (c *Config) Spawns() []Spawn {
+	return c.Rules().Spawns
+}
+
+func (c *Config) loadRules() {
+	if c.Map == "" {
+		c.Map = MapTheiaSkyRuins
+	}
+
+	file := filepath.Join(c.Maps(), c.Map+".json")
+
+	r, err := openRules(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			notify.Error("Map \"%s\" does not exist, %s is missing, using %s rules instead", c.Map, file, TheiaSkyRuins.Name)
+		} else {
+			notify.Error("Failed to load \"%s\" map rules, using %s rules instead (%v)", c.Map, TheiaSkyRuins.Name, err)
+		}
+		c.rules = TheiaSkyRuins
+		return
+	}
+
+	c.rules = r
+}
+
+func (c *Config) secureFiles() []filter.Filter {
+	filters := []filter.Filter{}
+
+	for _, o := range c.Rules().Objectives {
+		filters = append(filters,
+			filter.New(team.Game, fmt.Sprintf("%s/game/%s_ally.png", c.ProfileAssets(), o.Name), o.Secured(team.Purple).Int(), false),
+			filter.New(team.Game, fmt.Sprintf("%s/game/%s_enemy.png", c.ProfileAssets(), o.Name), o.Secured(team.Orange).Int(), false),
+		)
+	}
+
+	return filters
+}
+
+func openRules(file string) (*Rules, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Rules{}
+
+	err = json.Unmarshal(b, r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case r.Match <= 0:
+		return nil, fmt.Errorf("invalid match length %d", r.Match)
+	case r.FinalStretch < 0 || r.FinalStretch >= r.Match:
+		return nil, fmt.Errorf("invalid final stretch %d", r.FinalStretch)
+	case r.Multiplier < 1:
+		return nil, fmt.Errorf("invalid final stretch multiplier %d", r.Multiplier)
+	}
+
+	for _, o := range r.Objectives {
+		switch {
+		case o.Lane != LaneTop && o.Lane != LaneBottom && o.Lane != LaneMiddle:
+			return nil, fmt.Errorf("invalid %s lane \"%s\"", o.Name, o.Lane)
+		case o.Lane == LaneTop && o.Slots < 1:
+			return nil, fmt.Errorf("invalid %s slots %d", o.Name, o.Slots)
+		case o.Secured(team.Purple) == state.Nothing:
+			return nil, fmt.Errorf("unknown %s event \"%s\"", o.Name, o.Event)
+		}
+	}
+
+	for i, g := range r.Goals {
+		switch {
+		case g.Tier < 1:
+			return nil, fmt.Errorf("invalid goal #%d tier %d", i+1, g.Tier)
+		case g.Lane != LaneTop && g.Lane != LaneBottom && g.Lane != LaneMiddle:
+			return nil, fmt.Errorf("invalid goal #%d lane \"%s\"", i+1, g.Lane)
+		case g.X < 0 || g.X > 1 || g.Y < 0 || g.Y > 1:
+			return nil, fmt.Errorf("invalid goal #%d position %.2f,%.2f", i+1, g.X, g.Y)
+		case g.Capacity < 0:
+			return nil, fmt.Errorf("invalid goal #%d capacity %d", i+1, g.Capacity)
+		}
+	}
+
+	for _, s := range r.Spawns {
+		switch {
+		case s.Name == "":
+			return nil, fmt.Errorf("spawn without a name")
+		case len(s.Secures) == 0:
+			return nil, fmt.Errorf("%s spawn is not ended by any objective", s.Name)
+		case s.First <= 0 || s.First > r.Match:
+			return nil, fmt.Errorf("invalid %s first spawn %d", s.Name, s.First)
+		case s.Respawn < 0:
+			return nil, fmt.Errorf("invalid %s respawn %d", s.Name, s.Respawn)
+		case s.Until < 0 || s.Until > s.First:
+			return nil, fmt.Errorf("invalid %s last spawn %d", s.Name, s.Until)
+		}
+
+		for _, name := range s.Secures {
+			_, ok := r.Objective(name)
+			if !ok {
+				return nil, fmt.Errorf("%s spawn is ended by unknown objective \"%s\"", s.Name, name)
+			}
+		}
+	}
+
+	return r, nil
+}
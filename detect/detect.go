@@ -3,11 +3,10 @@ package detect
 import (
 	"fmt"
 	"image"
+	"image/draw"
 	"strings"
 	"time"
 
-	"gocv.io/x/gocv"
-
 	"github.com/pidgy/unitehud/config"
 	"github.com/pidgy/unitehud/debug"
 	"github.com/pidgy/unitehud/duplicate"
@@ -18,8 +17,8 @@ import (
 	"github.com/pidgy/unitehud/splash"
 	"github.com/pidgy/unitehud/state"
 	"github.com/pidgy/unitehud/team"
+	"github.com/pidgy/unitehud/template"
 	"github.com/pidgy/unitehud/video"
-	"github.com/pidgy/unitehud/video/monitor"
 	"github.com/pidgy/unitehud/video/window"
 )
 
@@ -30,361 +29,316 @@ var (
 	Resume = func() { idle = false }
 )
 
-func Clock() {
-	for {
-		sleep(team.Delay(team.Time.Name))
-
-		if idle || config.Current.DisableTime {
-			continue
-		}
-
-		matrix, img, err := capture(config.Current.Time)
-		if err != nil {
-			notify.Error("Failed to capture clock area (%v)", err)
-			continue
-		}
+// suspicious is the margin above the configured acceptance below which score matches are held for review.
+const suspicious = .03
+
+func init() {
+	Register(clock())
+	Register(defeated())
+	Register(energy())
+	Register(kos())
+	Register(objectives())
+	Register(pressButtonToScore())
+	Register(states())
+	Register(scores(team.Purple.Name))
+	Register(scores(team.Orange.Name))
+	Register(scores(team.First.Name))
+}
 
-		rs, kitchen := match.Time(matrix, img)
-		if rs == 0 {
-			// Let's back off and not waste processing power.
-			sleep(time.Second * 5)
-			continue
-		}
+func clock() Detector {
+	// resume is the capture time of the next frame processed after the clock was not found.
+	resume := time.Time{}
+
+	return New("Clock",
+		func() image.Rectangle { return config.Current.Time },
+		func() time.Duration { return team.Delay(team.Time.Name) },
+		func() *bool { return &config.Current.DisableTime },
+		func(f Frame) {
+			if f.Idle {
+				resume = time.Time{}
+				return
+			}
 
-		notify.Time, err = match.AsTimeImage(matrix, kitchen)
-		if err != nil {
-			notify.Error("Failed to identify time (%v)", err)
-			continue
-		}
-	}
-}
+			if f.Before(resume) {
+				f.Close()
+				return
+			}
 
-func Defeated() {
-	area := image.Rectangle{}
-	modified := config.Current.TemplatesKilled(team.Game.Name)
-	unmodified := config.Current.TemplatesKilled(team.Game.Name)
+			rs, kitchen := match.Time(f.Matrix, f.Image)
+			if rs == 0 {
+				f.Close()
 
-	for {
-		sleep(time.Second)
+				// Let's back off and not waste processing power.
+				resume = f.Add(rate(time.Second * 5))
+				return
+			}
 
-		if idle || config.Current.DisableDefeated {
-			modified = config.Current.TemplatesKilled(team.Game.Name)
-			unmodified = config.Current.TemplatesKilled(team.Game.Name)
-			continue
-		}
+			var err error
 
-		if area.Empty() {
-			b := monitor.MainResolution()
-			area = image.Rect(b.Max.X/3, b.Max.Y/2, b.Max.X-b.Max.X/3, b.Max.Y-b.Max.Y/3)
-		}
+			notify.Time, err = match.AsTimeImage(f.Matrix, kitchen)
+			if err != nil {
+				notify.Error("Failed to identify time (%v)", err)
+			}
 
-		matrix, img, err := capture(area)
-		if err != nil {
-			notify.Error("Failed to capture defeated area (%v)", err)
-			continue
-		}
+			f.Close()
+		},
+	)
+}
 
-		m, r, p := match.Matches(matrix, img, modified)
-		switch r {
-		case match.Found:
-			e := state.EventType(m.Template.Value)
+func defeated() Detector {
+	area := image.Rectangle{}
 
-			state.Add(e, server.Clock(), p)
+	// Templates are loaded with the first frame processed, once the configuration is loaded.
+	modified, unmodified := []*template.Template(nil), []*template.Template(nil)
 
-			switch e {
-			case state.Killed:
-				modified = modified[1:] // Remove killed templates for processing.
-				team.Self.Killed = time.Now()
-				team.Self.KilledWithPoints = false
-			case state.KilledWithPoints:
-				modified = modified[1:] // Remove killed templates for processing.
-				team.Self.Killed = time.Now()
-				team.Self.KilledWithPoints = true
-			case state.KilledWithoutPoints:
-				modified = modified[1:] // Remove killed templates for processing.
-				team.Self.Killed = time.Now()
-				team.Self.KilledWithPoints = false
+	return New("Defeated",
+		func() image.Rectangle {
+			if area.Empty() {
+				area = video.DefeatedArea()
+			}
+			return area
+		},
+		func() time.Duration { return time.Second },
+		func() *bool { return &config.Current.DisableDefeated },
+		func(f Frame) {
+			if f.Idle {
+				modified, unmodified = nil, nil
+				return
 			}
 
-			str := "Defeated"
-			if team.Self.KilledWithPoints {
-				str = fmt.Sprintf("%s with unscored points (%d)", str, server.Holding())
+			if unmodified == nil {
+				modified = config.Current.TemplatesKilled(team.Game.Name)
+				unmodified = config.Current.TemplatesKilled(team.Game.Name)
 			}
 
-			notify.Feed(team.Self.NRGBA, "[%s] [Self] %s", server.Clock(), str)
+			m, r, p := match.Matches(f.Matrix, f.Image, modified)
+			switch r {
+			case match.Found:
+				e := state.EventType(m.Template.Value)
+
+				state.AddPayload(e, server.Clock(), p, state.Detected("Defeated", m.Accepted, f.Time))
+
+				switch e {
+				case state.Killed:
+					modified = modified[1:] // Remove killed templates for processing.
+					team.Self.Killed = f.Time
+					team.Self.KilledWithPoints = false
+				case state.KilledWithPoints:
+					modified = modified[1:] // Remove killed templates for processing.
+					team.Self.Killed = f.Time
+					team.Self.KilledWithPoints = true
+				case state.KilledWithoutPoints:
+					modified = modified[1:] // Remove killed templates for processing.
+					team.Self.Killed = f.Time
+					team.Self.KilledWithPoints = false
+				}
+
+				str := "Defeated"
+				if team.Self.KilledWithPoints {
+					str = fmt.Sprintf("%s with unscored points (%d)", str, server.Holding())
+				}
+
+				notify.Feed(team.Self.NRGBA, "[%s] [Self] %s", server.Clock(), str)
 
-			if state.Occured(time.Minute, state.Killed, state.KilledWithPoints, state.KilledWithoutPoints) != nil {
-				server.SetDefeated()
+				if (state.Query{Types: state.Types{state.Killed, state.KilledWithPoints, state.KilledWithoutPoints}, Since: time.Minute}).Latest() != nil {
+					server.SetDefeated()
+				}
+			default:
+				modified = unmodified
 			}
-		default:
-			modified = unmodified
-		}
 
-		matrix.Close()
-	}
+			f.Close()
+		},
+	)
 }
 
-func Energy() {
+func energy() Detector {
 	assured := make(map[int]int)
 
 	confirmScore := -1
 
-	for {
-		sleep(team.Energy.Delay)
-
-		if idle || config.Current.DisableEnergy {
-			assured = make(map[int]int)
-			confirmScore = -1
-			continue
-		}
-
-		matrix, img, err := capture(config.Current.Energy)
-		if err != nil {
-			notify.Error("Failed to capture energy area (%v)", err)
-			continue
-		}
-
-		result, _, points := match.Energy(matrix, img)
-		if result != match.Found {
-			matrix.Close()
-			continue
-		}
-
-		// TODO: Is it better to check if we have 0 points?
-		if confirmScore != -1 {
-			go energyScoredConfirm(confirmScore, points, time.Now())
-			confirmScore = -1
-		}
-
-		assured[points]++
-
-		threshold := 1
-		if points != team.Energy.Holding {
-			threshold = 2
-		}
-		/*
-			if team.Energy.Holding == 0 {
-				threshold = 3
-			} else if team.Energy.Holding != 0 && points != team.Energy.Holding {
-				threshold = 2
+	return New("Energy",
+		func() image.Rectangle { return config.Current.Energy },
+		func() time.Duration { return team.Energy.Delay },
+		func() *bool { return &config.Current.DisableEnergy },
+		func(f Frame) {
+			if f.Idle {
+				assured = make(map[int]int)
+				confirmScore = -1
+				return
 			}
-		*/
-		if assured[points] == threshold {
-			assured = make(map[int]int)
-		}
 
-		last := state.HoldingEnergy.Occured(time.Hour)
-		if last == nil || last.Value != points {
-			notify.Feed(team.Self.NRGBA, "[%s] [Self] Holding %d point%s", server.Clock(), points, s(points))
-			state.Add(state.HoldingEnergy, server.Clock(), points)
-
-			server.SetEnergy(points)
-
-			notify.Energy, err = match.AsAeosImage(matrix, points)
-			if err != nil {
-				notify.Warn("[Self] Failed to identify energy (%v)", err)
+			result, _, points := match.Energy(f.Matrix, f.Image)
+			if result != match.Found {
+				f.Close()
+				return
 			}
 
-			// Can we assume change from n, where n > 0, to 0 means a goal without being defeated?
-			if points == 0 || points < team.Energy.Holding {
-				confirmScore = team.Energy.Holding
+			// TODO: Is it better to check if we have 0 points?
+			if confirmScore != -1 {
+				// Subscribe before confirming so defeats detected while the goroutine starts are not missed.
+				defeated := state.Subscribe(state.Query{Types: state.Types{state.KilledWithPoints}}, 1)
+				go energyScoredConfirm(confirmScore, points, f.Time, defeated)
+				confirmScore = -1
 			}
 
-			team.Energy.Holding = points
-		}
+			assured[points]++
 
-		matrix.Close()
-	}
-}
+			threshold := 1
+			if points != team.Energy.Holding {
+				threshold = 2
+			}
+			/*
+				if team.Energy.Holding == 0 {
+					threshold = 3
+				} else if team.Energy.Holding != 0 && points != team.Energy.Holding {
+					threshold = 2
+				}
+			*/
+			if assured[points] == threshold {
+				assured = make(map[int]int)
+			}
 
-func KOs() {
-	var last *duplicate.Duplicate
+			last := state.HoldingEnergy.Occured(time.Hour)
+			if last == nil || last.Value != points {
+				notify.Feed(team.Self.NRGBA, "[%s] [Self] Holding %d point%s", server.Clock(), points, s(points))
+				state.AddPayload(state.HoldingEnergy, server.Clock(), points, state.Detected("Energy", 0, f.Time))
 
-	for {
-		sleep(time.Millisecond * 1500)
+				server.SetEnergy(points)
 
-		if idle || config.Current.DisableKOs {
-			last = nil
-			continue
-		}
+				var err error
 
-		matrix, img, err := capture(config.Current.KOs)
-		if err != nil {
-			notify.Error("Failed to capture objective area (%v)", err)
-			continue
-		}
+				notify.Energy, err = match.AsAeosImage(f.Matrix, points)
+				if err != nil {
+					notify.Warn("[Self] Failed to identify energy (%v)", err)
+				}
 
-		_, r, e := match.Matches(matrix, img, config.Current.TemplatesKO(team.Game.Name))
-		if r != match.Found {
-			matrix.Close()
-			continue
-		}
+				// Can we assume change from n, where n > 0, to 0 means a goal without being defeated?
+				if points == 0 || points < team.Energy.Holding {
+					confirmScore = team.Energy.Holding
+				}
 
-		dup := duplicate.New(-1, matrix, matrix.Region(image.Rect(10, 10, matrix.Cols()-10, matrix.Rows()-10)))
-		if dup.Pixels(last) {
-			if time.Since(last.Time) < time.Second*10 {
-				matrix.Close()
-				continue
+				team.Energy.Holding = points
 			}
-		}
-
-		last.Close()
-		last = dup
 
-		switch e := state.EventType(e); e {
-		case state.KOPurple, state.KOStreakPurple:
-			notify.Unique(team.Purple.NRGBA, "[%s] [%s] %s", server.Clock(), team.Purple, e)
-			server.SetKO(team.Purple)
-		case state.KOOrange, state.KOStreakOrange:
-			notify.Unique(team.Orange.NRGBA, "[%s] [%s] %s", server.Clock(), team.Orange, e)
-			server.SetKO(team.Orange)
-		}
-	}
+			f.Close()
+		},
+	)
 }
 
-func Objectives() {
-	top, bottom, middle := time.Time{}, time.Time{}, time.Time{}
+func kos() Detector {
+	var last *duplicate.Duplicate
 
-	for {
-		sleep(time.Second)
+	return New("KOs",
+		func() image.Rectangle { return config.Current.KOs },
+		func() time.Duration { return time.Millisecond * 1500 },
+		func() *bool { return &config.Current.DisableKOs },
+		func(f Frame) {
+			defer f.Close()
 
-		if idle || config.Current.DisableObjectives {
-			top, bottom, middle = time.Time{}, time.Time{}, time.Time{}
-			continue
-		}
+			if f.Idle {
+				last = nil
+				return
+			}
 
-		matrix, img, err := capture(config.Current.Objectives)
-		if err != nil {
-			notify.Error("Failed to capture objective area (%v)", err)
-			continue
-		}
+			m, r, e := match.Matches(f.Matrix, f.Image, config.Current.TemplatesKO(team.Game.Name))
+			if r != match.Found {
+				return
+			}
 
-		_, r, e := match.Matches(matrix, img, config.Current.TemplatesSecure(team.Game.Name))
-		if r != match.Found {
-			matrix.Close()
-			continue
-		}
+			dup := duplicate.New(-1, f.Matrix, f.Matrix.Region(image.Rect(10, 10, f.Matrix.Cols()-10, f.Matrix.Rows()-10)))
+			if dup.Pixels(last) {
+				if f.Sub(last.Time) < time.Second*10 {
+					dup.Close()
+					return
+				}
+			}
+
+			last.Close()
+			last = dup
 
-		early := false
+			state.AddPayload(state.EventType(e), server.Clock(), -1, state.Detected("KOs", m.Accepted, f.Time))
 
-		if time.Since(top) > time.Minute {
 			switch e := state.EventType(e); e {
-			case state.RegielekiSecureOrange:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Orange.NRGBA, "[%s] [%s] Regieleki secured", server.Clock(), strings.Title(team.Orange.Name))
-				server.SetRegieleki(team.Orange)
-				top = time.Now()
+			case state.KOPurple, state.KOStreakPurple:
+				notify.Unique(team.Purple.NRGBA, "[%s] [%s] %s", server.Clock(), team.Purple, e)
+				server.SetKO(team.Purple)
+			case state.KOOrange, state.KOStreakOrange:
+				notify.Unique(team.Orange.NRGBA, "[%s] [%s] %s", server.Clock(), team.Orange, e)
+				server.SetKO(team.Orange)
+			}
+		},
+	)
+}
 
-				early = true
-			case state.RegielekiSecurePurple:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Purple.NRGBA, "[%s] [%s] Regieleki secured", server.Clock(), strings.Title(team.Purple.Name))
-				server.SetRegieleki(team.Purple)
-				top = time.Now()
+func objectives() Detector {
+	secured := map[string]time.Time{}
 
-				early = true
-			}
-		}
+	return New("Objectives",
+		func() image.Rectangle { return config.Current.Objectives },
+		func() time.Duration { return time.Second },
+		func() *bool { return &config.Current.DisableObjectives },
+		func(f Frame) {
+			defer f.Close()
 
-		if !early && time.Since(bottom) > time.Minute {
-			switch e := state.EventType(e); e {
-			case state.RegiceSecureOrange:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Orange.NRGBA, "[%s] [%s] Regice secured", server.Clock(), strings.Title(team.Orange.Name))
-				server.SetRegice(team.Orange)
-				bottom = time.Now()
-
-				early = true
-			case state.RegiceSecurePurple:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Purple.NRGBA, "[%s] [%s] Regice secured", server.Clock(), strings.Title(team.Purple.Name))
-				server.SetRegice(team.Purple)
-				bottom = time.Now()
-
-				early = true
-			case state.RegirockSecureOrange:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Orange.NRGBA, "[%s] [%s] Regirock secured", server.Clock(), strings.Title(team.Orange.Name))
-				server.SetRegirock(team.Orange)
-				bottom = time.Now()
-
-				early = true
-			case state.RegirockSecurePurple:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Purple.NRGBA, "[%s] [%s] Regirock secured", server.Clock(), strings.Title(team.Purple.Name))
-				server.SetRegirock(team.Purple)
-				bottom = time.Now()
-
-				early = true
-			case state.RegisteelSecureOrange:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Orange.NRGBA, "[%s] [%s] Registeel secured", server.Clock(), strings.Title(team.Orange.Name))
-				server.SetRegisteel(team.Orange)
-				bottom = time.Now()
-
-				early = true
-			case state.RegisteelSecurePurple:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Purple.NRGBA, "[%s] [%s] Registeel secured", server.Clock(), strings.Title(team.Purple.Name))
-				server.SetRegisteel(team.Purple)
-				bottom = time.Now()
-
-				early = true
+			if f.Idle {
+				secured = map[string]time.Time{}
+				return
 			}
-		}
-
-		if !early && time.Since(middle) > time.Minute {
-			switch e := state.EventType(e); e {
-			case state.RayquazaSecureOrange:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Orange.NRGBA, "[%s] [%s] Rayquaza secured", server.Clock(), strings.Title(team.Orange.Name))
-				server.SetRayquaza(team.Orange)
-				middle = time.Now()
 
-				early = true
-			case state.RayquazaSecurePurple:
-				state.Add(e, server.Clock(), 0)
-				notify.Feed(team.Purple.NRGBA, "[%s] [%s] Rayquaza secured", server.Clock(), strings.Title(team.Purple.Name))
-				server.SetRayquaza(team.Purple)
-				middle = time.Now()
+			m, r, e := match.Matches(f.Matrix, f.Image, config.Current.TemplatesSecure(team.Game.Name))
+			if r != match.Found {
+				return
+			}
 
-				early = true
+			o, t, ok := config.Current.Rules().Secured(state.EventType(e))
+			if !ok || f.Sub(secured[o.Lane]) <= time.Minute {
+				return
 			}
-		}
+			secured[o.Lane] = f.Time
 
-		matrix.Close()
-	}
+			state.AddPayload(state.EventType(e), server.Clock(), 0, state.Detected("Objectives", m.Accepted, f.Time))
+			notify.Feed(t.NRGBA, "[%s] [%s] %s secured", server.Clock(), strings.Title(t.Name), strings.Title(o.Name))
+			server.SetSecured(t, o.Name)
+		},
+	)
 }
 
-func PressButtonToScore() {
-	for {
-		sleep(time.Millisecond * 500)
-
-		if idle {
-			continue
-		}
+func pressButtonToScore() Detector {
+	// resume is the capture time of the next frame processed after the score option was found.
+	resume := time.Time{}
+
+	return New("Score Option",
+		func() image.Rectangle { return config.Current.ScoringOption() },
+		func() time.Duration { return time.Millisecond * 500 },
+		nil,
+		func(f Frame) {
+			if f.Idle {
+				resume = time.Time{}
+				return
+			}
 
-		matrix, img, err := capture(config.Current.ScoringOption())
-		if err != nil {
-			notify.Error("Failed to capture energy area (%v)", err)
-			continue
-		}
+			if f.Before(resume) {
+				f.Close()
+				return
+			}
 
-		_, r := match.SelfScoreOption(matrix, img)
-		if r != match.Found {
-			matrix.Close()
-			continue
-		}
+			_, r := match.SelfScoreOption(f.Matrix, f.Image)
+			if r != match.Found {
+				f.Close()
+				return
+			}
 
-		state.Add(state.PressButtonToScore, server.Clock(), team.Energy.Holding)
+			state.AddPayload(state.PressButtonToScore, server.Clock(), team.Energy.Holding, state.Detected("Score Option", 0, f.Time))
 
-		notify.Feed(team.Self.NRGBA, "[%s] [Self] Score option present (%d)", server.Clock(), team.Energy.Holding)
+			notify.Feed(team.Self.NRGBA, "[%s] [Self] Score option present (%d)", server.Clock(), team.Energy.Holding)
 
-		matrix.Close()
+			f.Close()
 
-		// Save some resources,
-		time.Sleep(time.Second * 2)
-	}
+			// Save some resources,
+			resume = f.Add(time.Second * 2)
+		},
+	)
 }
 
 func Preview() {
@@ -402,6 +356,11 @@ func Preview() {
 			notify.Error("Failed to capture preview (%v)", err)
 			return
 		}
+
+		if img == nil {
+			return
+		}
+
 		notify.Preview = img
 
 		if config.Current.Window != window && config.Current.VideoCaptureDevice != device {
@@ -433,180 +392,202 @@ func Preview() {
 	}
 }
 
-func Scores(name string) {
-	for {
-		sleep(team.Delay(name))
+func scores(name string) Detector {
+	return New(strings.Title(name)+" Score",
+		func() image.Rectangle { return config.Current.Scores },
+		func() time.Duration { return team.Delay(name) },
+		func() *bool { return &config.Current.DisableScoring },
+		func(f Frame) {
+			if f.Idle {
+				return
+			}
 
-		if idle || config.Current.DisableScoring {
-			continue
-		}
+			if name == team.First.Name && team.First.Counted {
+				f.Close()
+				return
+			}
 
-		if name == team.First.Name && team.First.Counted {
-			continue
-		}
+			m, r, p := match.Matches(f.Matrix, f.Image, config.Current.TemplatesScored(name))
+			if r == match.NotFound {
+				f.Close()
+				return
+			}
 
-		matrix, img, err := capture(config.Current.Scores)
-		if err != nil {
-			notify.Error("Failed to capture score area (%v)", err)
-			continue
-		}
+			switch r {
+			case match.Override:
+				override := state.Detected(strings.Title(name)+" Score", m.Accepted, f.Time)
+				override.Team = side(m.Team)
+				override.Points = -m.Team.Duplicate.Replaces
 
-		m, r, p := match.Matches(matrix, img, config.Current.TemplatesScored(name))
-		if r == match.NotFound {
-			matrix.Close()
-			continue
-		}
+				// Overrides replace a score that was already counted, and are held until reviewed.
+				state.Hold(state.ScoreOverride, server.Clock(), p, override, snapshot(f))
 
-		switch r {
-		case match.Override:
-			state.Add(state.ScoreOverride, server.Clock(), p)
+				notify.Warn("[%s] [%s] -%d (override held for review)", server.Clock(), strings.Title(m.Team.Name), m.Team.Duplicate.Replaces)
 
-			server.SetScore(m.Team, -m.Team.Duplicate.Replaces)
+				fallthrough
+			case match.Found:
+				title := fmt.Sprintf("[%s]", strings.Title(m.Team.Name))
+				if m.Team.Name == team.First.Name {
+					title = fmt.Sprintf("[%s] [%s]", strings.Title(m.Team.Alias), strings.Title(m.Team.Name))
+				}
 
-			notify.Feed(m.Team.NRGBA, "[%s] [%s] -%d (override)", server.Clock(), strings.Title(m.Team.Name), m.Team.Duplicate.Replaces)
+				detected := state.Detected(strings.Title(name)+" Score", m.Accepted, f.Time)
+				detected.Team = side(m.Team)
+				detected.Points = p
 
-			fallthrough
-		case match.Found:
-			server.SetScore(m.Team, p)
+				if m.Accepted < config.Current.Acceptance+suspicious {
+					state.Hold(state.ScoredBy(m.Team.Name), server.Clock(), p, detected, snapshot(f))
 
-			title := fmt.Sprintf("[%s]", strings.Title(m.Team.Name))
-			if m.Team.Name == team.First.Name {
-				title = fmt.Sprintf("[%s] [%s]", strings.Title(m.Team.Alias), strings.Title(m.Team.Name))
-			}
+					notify.Warn("[%s] %s +%d (held for review, %.0f%% confidence)", server.Clock(), title, p, m.Accepted*100)
+					break
+				}
 
-			notify.Feed(m.Team.NRGBA, "[%s] %s +%d", server.Clock(), title, p)
+				server.SetScore(m.Team, p)
 
-			state.Add(state.ScoredBy(m.Team.Name), server.Clock(), p)
+				notify.Feed(m.Team.NRGBA, "[%s] %s +%d", server.Clock(), title, p)
 
-			score, err := m.AsImage(matrix, p)
-			if err != nil {
-				notify.Error("[%s] [%s] Failed to identify score (%v)", server.Clock(), strings.Title(m.Team.Name), err)
-				break
-			}
+				state.AddPayload(state.ScoredBy(m.Team.Name), server.Clock(), p, detected)
+
+				score, err := m.AsImage(f.Matrix, p)
+				if err != nil {
+					notify.Error("[%s] [%s] Failed to identify score (%v)", server.Clock(), strings.Title(m.Team.Name), err)
+					break
+				}
 
-			switch m.Team.Name {
-			case team.First.Name:
-				if team.First.Alias == team.Purple.Name {
+				switch m.Team.Name {
+				case team.First.Name:
+					if team.First.Alias == team.Purple.Name {
+						notify.PurpleScore = score
+					} else {
+						notify.OrangeScore = score
+					}
+				case team.Purple.Name:
 					notify.PurpleScore = score
-				} else {
+				case team.Orange.Name:
 					notify.OrangeScore = score
 				}
-			case team.Purple.Name:
-				notify.PurpleScore = score
-			case team.Orange.Name:
-				notify.OrangeScore = score
-			}
-		case match.Missed:
-			state.Add(state.ScoreMissedBy(m.Team.Name), server.Clock(), p)
-
-			notify.Error("[%s] [%s] +%d (missed)", server.Clock(), strings.Title(m.Team.Name), p)
-		case match.Invalid:
-			notify.Error("[%s] [%s] +%d (invalid)", server.Clock(), strings.Title(m.Team.Name), p)
-		case match.Duplicate:
-			notify.Warn("[%s] [%s] +%d (duplicate)", server.Clock(), strings.Title(m.Team.Name), p)
-		}
+			case match.Missed:
+				missed := state.Detected(strings.Title(name)+" Score", m.Accepted, f.Time)
+				missed.Team = side(m.Team)
 
-		if config.Current.Record {
-			debug.Capture(img, matrix, m.Team, m.Point, p, r)
-		}
+				state.Hold(state.ScoreMissedBy(missed.Team), server.Clock(), p, missed, snapshot(f))
+
+				notify.Error("[%s] [%s] +%d (missed, held for review)", server.Clock(), strings.Title(m.Team.Name), p)
+			case match.Invalid:
+				notify.Error("[%s] [%s] +%d (invalid)", server.Clock(), strings.Title(m.Team.Name), p)
+			case match.Duplicate:
+				notify.Warn("[%s] [%s] +%d (duplicate)", server.Clock(), strings.Title(m.Team.Name), p)
+			}
+
+			if config.Current.Record {
+				debug.Capture(f.Image, f.Matrix, m.Team, m.Point, p, r)
+			}
+
+			f.Close()
+		},
+	)
+}
 
-		matrix.Close()
+// side returns the team credited with a score, resolving the side that scored the first goal.
+func side(t *team.Team) string {
+	if t.Name == team.First.Name {
+		return t.Alias
 	}
+	return t.Name
+}
+
+// snapshot returns a copy of a frame that outlives it, to review the events detected in it.
+func snapshot(f Frame) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, f.Image.Bounds().Dx(), f.Image.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), f.Image, f.Image.Bounds().Min, draw.Src)
+	return img
 }
 
-func States() {
+func states() Detector {
 	area := image.Rectangle{}
 
-	for {
-		sleep(time.Second * 2)
+	// cleared is the capture time after which the results of an ended match are cleared.
+	cleared := time.Time{}
 
-		if idle {
-			continue
-		}
+	return New("States",
+		func() image.Rectangle {
+			if area.Empty() {
+				area = video.StateArea()
+			}
+			return area
+		},
+		func() time.Duration { return time.Second * 2 },
+		nil,
+		func(f Frame) {
+			if !cleared.IsZero() && !f.Before(cleared) {
+				cleared = time.Time{}
+
+				server.Clear()
+				team.Clear()
+			}
 
-		if area.Empty() {
-			area = video.StateArea()
-		}
+			if f.Idle {
+				return
+			}
 
-		matrix, img, err := capture(area)
-		if err != nil {
-			notify.Error("Failed to capture state area (%v)", err)
-			matrix.Close()
-			continue
-		}
+			// Results of an ended match are shown until they are cleared.
+			if !cleared.IsZero() {
+				f.Close()
+				return
+			}
 
-		m, r, e := match.Matches(matrix, img, config.Current.TemplatesGame(team.Game.Name))
-		if r != match.Found {
-			matrix.Close()
-			continue
-		}
+			m, r, e := match.Matches(f.Matrix, f.Image, config.Current.TemplatesGame(team.Game.Name))
+			if r != match.Found {
+				f.Close()
+				return
+			}
 
-		state.Add(state.EventType(m.Template.Value), server.Clock(), -1)
+			detected := state.Detected("States", m.Accepted, f.Time)
 
-		switch e := state.EventType(e); e {
-		case state.MatchStarting:
-			if server.Clock() == "10:00" {
-				matrix.Close()
-				continue
+			// Match starts are added after the events of the previous match are cleared, to begin its journal.
+			if state.EventType(m.Template.Value) != state.MatchStarting {
+				state.AddPayload(state.EventType(m.Template.Value), server.Clock(), -1, detected)
 			}
 
-			server.Clear()
-			server.SetMatchStarted()
+			switch e := state.EventType(e); e {
+			case state.MatchStarting:
+				if server.Seconds() == config.Current.Rules().Match {
+					f.Close()
+					return
+				}
 
-			team.Clear()
-			state.Clear()
+				server.Clear()
+				server.SetMatchStarted()
 
-			notify.Feed(team.Game.NRGBA, "[%s] Match starting", strings.Title(team.Game.Name))
+				team.Clear()
+				state.Clear()
 
-			// Also tells javascript to turn on.
-			server.SetTime(10, 0)
-		case state.MatchEnding:
-			switch config.Current.Profile {
-			case config.ProfileBroadcaster:
-				if !server.Match() {
-					break
-				}
+				state.AddPayload(state.MatchStarting, server.Clock(), -1, detected)
+
+				notify.Feed(team.Game.NRGBA, "[%s] Match starting", strings.Title(team.Game.Name))
 
-				notify.Feed(team.Game.NRGBA, "[%s] Match ended", strings.Title(team.Game.Name))
-
-				// Purple score and objective results.
-				regielekis, regices, regirocks, registeels, rayquazas := server.Objectives(team.Purple)
-				notify.Feed(team.Purple.NRGBA,
-					"[%s] [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
-					strings.Title(team.Purple.Name),
-					server.KOs(team.Purple), s(server.KOs(team.Purple)),
-					regielekis, s(regielekis),
-					regices, s(regices),
-					regirocks, s(regirocks),
-					registeels, s(registeels),
-					rayquazas,
-				)
-
-				// Orange score and objective results.
-				regielekis, regices, regirocks, registeels, rayquazas = server.Objectives(team.Orange)
-				orangeResult := fmt.Sprintf(
-					"[%s] [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
-					strings.Title(team.Orange.Name),
-					server.KOs(team.Orange), s(server.KOs(team.Orange)),
-					regielekis, s(regielekis),
-					regices, s(regices),
-					regirocks, s(regirocks),
-					registeels, s(registeels),
-					rayquazas,
-				)
-
-				notify.Feed(team.Orange.NRGBA, orangeResult)
-			case config.ProfilePlayer:
+				// Also tells javascript to turn on.
+				seconds := config.Current.Rules().Match
+				server.SetTime(seconds/60, seconds%60)
+			case state.MatchEnding:
 				o, p, self := server.Scores()
-				if o+p+self > 0 {
+				if !server.MatchStarted().IsZero() || o+p+self > 0 {
+					record(p, o, self)
+				}
+
+				switch config.Current.Profile {
+				case config.ProfileBroadcaster:
+					if !server.Match() {
+						break
+					}
+
 					notify.Feed(team.Game.NRGBA, "[%s] Match ended", strings.Title(team.Game.Name))
 
 					// Purple score and objective results.
 					regielekis, regices, regirocks, registeels, rayquazas := server.Objectives(team.Purple)
 					notify.Feed(team.Purple.NRGBA,
-						"[%s] %d [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
+						"[%s] [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
 						strings.Title(team.Purple.Name),
-						p,
 						server.KOs(team.Purple), s(server.KOs(team.Purple)),
 						regielekis, s(regielekis),
 						regices, s(regices),
@@ -617,10 +598,9 @@ func States() {
 
 					// Orange score and objective results.
 					regielekis, regices, regirocks, registeels, rayquazas = server.Objectives(team.Orange)
-					notify.Feed(team.Orange.NRGBA,
-						"[%s] %d [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
+					orangeResult := fmt.Sprintf(
+						"[%s] [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
 						strings.Title(team.Orange.Name),
-						o,
 						server.KOs(team.Orange), s(server.KOs(team.Orange)),
 						regielekis, s(regielekis),
 						regices, s(regices),
@@ -629,31 +609,66 @@ func States() {
 						rayquazas,
 					)
 
-					// Self score and objective results.
-					notify.Feed(team.Self.NRGBA, "[%s] %d", strings.Title(team.Self.Name), self)
-
-					history.Add(p, o, self)
+					notify.Feed(team.Orange.NRGBA, orangeResult)
+				case config.ProfilePlayer:
+					if o+p+self > 0 {
+						notify.Feed(team.Game.NRGBA, "[%s] Match ended", strings.Title(team.Game.Name))
+
+						// Purple score and objective results.
+						regielekis, regices, regirocks, registeels, rayquazas := server.Objectives(team.Purple)
+						notify.Feed(team.Purple.NRGBA,
+							"[%s] %d [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
+							strings.Title(team.Purple.Name),
+							p,
+							server.KOs(team.Purple), s(server.KOs(team.Purple)),
+							regielekis, s(regielekis),
+							regices, s(regices),
+							regirocks, s(regirocks),
+							registeels, s(registeels),
+							rayquazas,
+						)
+
+						// Orange score and objective results.
+						regielekis, regices, regirocks, registeels, rayquazas = server.Objectives(team.Orange)
+						notify.Feed(team.Orange.NRGBA,
+							"[%s] %d [+%d KO%s] [+%d Regieleki%s] [+%d Regice%s] [+%d Regirock%s] [+%d Registeel%s] [+%d Rayquazas]",
+							strings.Title(team.Orange.Name),
+							o,
+							server.KOs(team.Orange), s(server.KOs(team.Orange)),
+							regielekis, s(regielekis),
+							regices, s(regices),
+							regirocks, s(regirocks),
+							registeels, s(registeels),
+							rayquazas,
+						)
+
+						// Self score and objective results.
+						notify.Feed(team.Self.NRGBA, "[%s] %d", strings.Title(team.Self.Name), self)
+					}
 				}
-			}
 
-			// If time since match started is greater thaaaan 2 mins lets wait for 10 seconds...
-			cooldown := time.Second * 0
-			start := state.MatchStarting.Occured(time.Since(state.Start().Time))
-			if start != nil {
-				end := state.MatchEnding.Occured(time.Since(state.Start().Time))
-				if end != nil && start.After(end.Time) {
-					cooldown = time.Second * 10
+				// If time since match started is greater thaaaan 2 mins lets wait for 10 seconds...
+				cooldown := time.Second * 0
+				start := state.MatchStarting.Occured(time.Since(state.Start().Time))
+				if start != nil {
+					end := state.MatchEnding.Occured(time.Since(state.Start().Time))
+					if end != nil && start.After(end.Time) {
+						cooldown = time.Second * 10
+					}
 				}
-			}
 
-			time.Sleep(cooldown)
+				if cooldown == 0 {
+					server.Clear()
+					team.Clear()
+					break
+				}
 
-			server.Clear()
-			team.Clear()
-		}
+				cleared = f.Add(cooldown)
+			}
 
-		matrix.Close()
-	}
+			f.Close()
+		},
+	)
 }
 
 func Window() {
@@ -671,20 +686,6 @@ func Window() {
 	}
 }
 
-func capture(area image.Rectangle) (gocv.Mat, *image.RGBA, error) {
-	img, err := video.CaptureRect(area)
-	if err != nil {
-		return gocv.Mat{}, nil, err
-	}
-
-	m, err := gocv.ImageToMatRGB(img)
-	if err != nil {
-		return gocv.Mat{}, nil, err
-	}
-
-	return m, img, nil
-}
-
 // energyScoredConfirm is another step to confirm a self-score event occured. This function
 // handles multiple edge cases that can result in invalid detections, such as:
 //   - Interrupted score attempts.
@@ -692,8 +693,10 @@ func capture(area image.Rectangle) (gocv.Mat, *image.RGBA, error) {
 //   - ...
 //
 // If a call is made to this function it is because UniteHUD has detected were holding 0 points
-// after a confirmed score match.
-func energyScoredConfirm(before, after int, at time.Time) {
+// after a confirmed score match. The subscription to defeats is closed when the score is confirmed.
+func energyScoredConfirm(before, after int, at time.Time, defeated *state.Subscription) {
+	defer defeated.Close()
+
 	if before == after {
 		return
 	}
@@ -706,22 +709,30 @@ func energyScoredConfirm(before, after int, at time.Time) {
 		time.Since(at),
 	)
 
-	// Confirm user was not defeated with points since the goal.
-	if state.KilledWithPoints.Occured(time.Second*2) != nil {
+	// Confirm user was not defeated with points around the goal, waiting for the defeated detector to
+	// process the frames captured since.
+	if (state.Query{Types: state.Types{state.KilledWithPoints}, From: at.Add(-time.Second * 2)}).Latest() != nil {
 		notify.Warn("[%s] Failed to score because you were defeated (-%d)", server.Clock(), before)
 		return
 	}
 
-	p := state.PressButtonToScore.Occured(time.Second * 5)
+	select {
+	case <-defeated.Events:
+		notify.Warn("[%s] Failed to score because you were defeated (-%d)", server.Clock(), before)
+		return
+	case <-time.After(time.Until(at.Add(time.Second * 2))):
+	}
+
+	p := state.Query{Types: state.Types{state.PressButtonToScore}, From: at.Add(-time.Second * 5)}.Latest()
 	if p != nil && !p.Verified {
-		p.Verified = true
+		p.Verify()
 	} else {
 		notify.Warn("[%s] [Self] Failed to score because the score option was not present (-%d)", server.Clock(), before)
 		return
 	}
 
 	if server.IsFinalStretch() {
-		before *= 2
+		before *= config.Current.Rules().Multiplier
 	}
 
 	go server.SetScore(team.Self, before)
@@ -737,6 +748,39 @@ func energyScoredConfirm(before, after int, at time.Time) {
 	)
 }
 
+// record stores the final results of a match in the history database and exports them.
+func record(purple, orange, self int) {
+	m := history.Match{
+		Profile:  config.Current.Profile,
+		Platform: config.Current.Platform,
+		Purple:   history.Team{Score: purple, KOs: server.KOs(team.Purple)},
+		Orange:   history.Team{Score: orange, KOs: server.KOs(team.Orange)},
+		Self:     history.Self{Score: self},
+		Events:   history.Events(state.Events()),
+	}
+
+	m.Purple.Regielekis, m.Purple.Regices, m.Purple.Regirocks, m.Purple.Registeels, m.Purple.Rayquazas = server.Objectives(team.Purple)
+	m.Orange.Regielekis, m.Orange.Regices, m.Orange.Regirocks, m.Orange.Registeels, m.Orange.Rayquazas = server.Objectives(team.Orange)
+
+	start := server.MatchStarted()
+	if !start.IsZero() {
+		m.Duration = int(time.Since(start).Seconds())
+	}
+
+	m, err := history.Add(m)
+	if err != nil {
+		notify.Error("Failed to save match to %s (%v)", history.File, err)
+		return
+	}
+
+	notify.System("Saved match #%d to %s", m.ID, history.File)
+
+	err = history.Export(m)
+	if err != nil {
+		notify.Error("Failed to export match #%d to %s (%v)", m.ID, history.Exports, err)
+	}
+}
+
 func s(size int) string {
 	if size == 1 {
 		return ""
@@ -744,7 +788,8 @@ func s(size int) string {
 	return "s"
 }
 
-func sleep(d time.Duration) {
+// rate returns a delay reduced by the increased capture rate percentage.
+func rate(d time.Duration) time.Duration {
 	delta := time.Duration(float64(d) * (float64(config.Current.Advanced.IncreasedCaptureRate) / 100))
-	time.Sleep(d - delta)
+	return d - delta
 }
@@ -0,0 +1,252 @@
+package detect
+
+import (
+	"encoding/json"
+	"image"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pidgy/unitehud/server"
+)
+
+// Detector finds events in an area of the frames captured by Frames. Detectors are added with Register,
+// and run from Start until the process exits.
+type Detector interface {
+	Name() string
+	// Area is the region of the frame sent to Process.
+	Area() image.Rectangle
+	// Interval is the delay between frames, before the increased capture rate is applied.
+	Interval() time.Duration
+	// Process is called with every frame, and must close frames that are not idle.
+	Process(f Frame)
+	Enabled() bool
+	Enable(enabled bool)
+	Health() Health
+}
+
+// Health describes the frames processed by a detector.
+type Health struct {
+	Frames  int           // Frames processed, excluding idle frames.
+	Dropped int           // Frames replaced by a newer frame before they were processed.
+	Average time.Duration // Average duration of Process.
+	Last    time.Time     // Capture time of the last frame processed.
+}
+
+type detector struct {
+	name     string
+	area     func() image.Rectangle
+	interval func() time.Duration
+	disabled func() *bool
+	process  func(f Frame)
+
+	health Health
+	off    bool
+
+	mutex *sync.Mutex
+}
+
+type registered struct {
+	Detector
+
+	next   time.Time
+	frames chan Frame
+}
+
+var (
+	registry      = []*registered{}
+	registryMutex = &sync.RWMutex{}
+
+	started = false
+)
+
+func init() {
+	server.Detectors = func() []server.Detector {
+		d := []server.Detector{}
+		for _, r := range Detectors() {
+			d = append(d, r)
+		}
+		return d
+	}
+}
+
+// New returns a Detector that calls process with every frame of an area. Disabled returns the configuration
+// toggle of the detector, or nil for detectors that are only disabled through Enable.
+func New(name string, area func() image.Rectangle, interval func() time.Duration, disabled func() *bool, process func(f Frame)) Detector {
+	return &detector{
+		name:     name,
+		area:     area,
+		interval: interval,
+		disabled: disabled,
+		process:  process,
+		mutex:    &sync.Mutex{},
+	}
+}
+
+// Detectors returns every registered detector, ordered by name.
+func Detectors() []Detector {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	d := []Detector{}
+	for _, r := range registry {
+		d = append(d, r.Detector)
+	}
+
+	sort.Slice(d, func(i, j int) bool { return d[i].Name() < d[j].Name() })
+
+	return d
+}
+
+// Register adds a detector to the frames captured by Frames. Detectors registered after Start are
+// started immediately.
+func Register(d Detector) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	r := &registered{
+		Detector: d,
+		frames:   make(chan Frame, 1),
+	}
+
+	registry = append(registry, r)
+
+	if started {
+		go r.run()
+	}
+}
+
+// Start starts every registered detector, and the frame producer feeding them.
+func Start() {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if started {
+		return
+	}
+	started = true
+
+	for _, r := range registry {
+		go r.run()
+	}
+
+	go Frames()
+}
+
+func (d *detector) Area() image.Rectangle {
+	return d.area()
+}
+
+func (d *detector) Enable(enabled bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.disabled != nil {
+		*d.disabled() = !enabled
+		return
+	}
+
+	d.off = !enabled
+}
+
+func (d *detector) Enabled() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.disabled != nil {
+		return !*d.disabled()
+	}
+
+	return !d.off
+}
+
+func (d *detector) Health() Health {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.health
+}
+
+func (d *detector) Interval() time.Duration {
+	return d.interval()
+}
+
+func (d *detector) MarshalJSON() ([]byte, error) {
+	h := d.Health()
+
+	return json.Marshal(struct {
+		Name     string  `json:"name"`
+		Enabled  bool    `json:"enabled"`
+		Interval int64   `json:"interval"`
+		Frames   int     `json:"frames"`
+		Dropped  int     `json:"dropped"`
+		Average  float64 `json:"average"`
+		Last     int64   `json:"last"`
+	}{
+		Name:     d.name,
+		Enabled:  d.Enabled(),
+		Interval: d.Interval().Milliseconds(),
+		Frames:   h.Frames,
+		Dropped:  h.Dropped,
+		Average:  float64(h.Average.Microseconds()) / 1000,
+		Last:     h.Last.Unix(),
+	})
+}
+
+func (d *detector) Name() string {
+	return d.name
+}
+
+func (d *detector) Process(f Frame) {
+	if f.Idle {
+		d.process(f)
+		return
+	}
+
+	start := time.Now()
+
+	d.process(f)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.health.Frames++
+	d.health.Average += (time.Since(start) - d.health.Average) / time.Duration(d.health.Frames)
+	d.health.Last = f.Time
+}
+
+// dropped records a frame replaced before it was processed.
+func (d *detector) dropped() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.health.Dropped++
+}
+
+func (r *registered) run() {
+	for f := range r.frames {
+		r.Process(f)
+	}
+}
+
+// send replaces any frame the detector has yet to receive, so busy detectors always process the most
+// recent capture.
+func (r *registered) send(f Frame) {
+	select {
+	case r.frames <- f:
+		return
+	default:
+	}
+
+	select {
+	case stale := <-r.frames:
+		stale.Close()
+
+		if d, ok := r.Detector.(*detector); ok && !stale.Idle {
+			d.dropped()
+		}
+	default:
+	}
+
+	r.frames <- f
+}
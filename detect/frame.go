@@ -0,0 +1,138 @@
+package detect
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/video"
+)
+
+// Frame is an area of a single capture of the video source, shared by every detector due to process a
+// frame at the time of the capture.
+type Frame struct {
+	Matrix gocv.Mat
+	Image  *image.RGBA
+
+	// Idle frames are sent without a capture while detection is paused, or the detector is disabled.
+	Idle bool
+
+	time.Time
+
+	scale float64 // Scale the capture was divided by, see normalize.
+}
+
+var tick = time.Millisecond * 50
+
+// Frames captures the video source at most once per tick, converts and rescales the capture once, and
+// sends an area of it to every registered detector whose interval has passed.
+func Frames() {
+	for {
+		time.Sleep(tick)
+
+		now := time.Now()
+
+		due := []*registered{}
+		active := false
+
+		registryMutex.RLock()
+		for _, r := range registry {
+			if now.Before(r.next) {
+				continue
+			}
+
+			r.next = now.Add(rate(r.Interval()))
+			due = append(due, r)
+
+			active = active || (!idle && r.Enabled())
+		}
+		registryMutex.RUnlock()
+
+		if len(due) == 0 {
+			continue
+		}
+
+		captured := Frame{}
+		if active {
+			var err error
+			captured, err = capture(now)
+			if err != nil {
+				notify.Error("Failed to capture frame (%v)", err)
+				continue
+			}
+		}
+
+		for _, r := range due {
+			if idle || !r.Enabled() || captured.Image == nil {
+				r.send(Frame{Idle: true, Time: now})
+				continue
+			}
+
+			f, err := captured.region(r.Area())
+			if err != nil {
+				notify.Error("Failed to capture %s area (%v)", r.Name(), err)
+				continue
+			}
+
+			r.send(f)
+		}
+
+		captured.Close()
+	}
+}
+
+// Close releases the matrix of a frame that is not idle.
+func (f Frame) Close() {
+	if f.Image == nil {
+		return
+	}
+	f.Matrix.Close()
+}
+
+// region returns a copy of an area of the capture, rescaled with the frame matrix, and a view of the same
+// area of the frame image.
+func (f Frame) region(area image.Rectangle) (Frame, error) {
+	b := f.Image.Bounds()
+
+	r := rescale(area, f.scale).Add(b.Min).Intersect(b)
+	if r.Empty() {
+		return Frame{}, fmt.Errorf("%s is outside of the %s frame", area, b.Size())
+	}
+
+	mat := f.Matrix.Region(r.Sub(b.Min))
+	defer mat.Close()
+
+	sub := f.Image.SubImage(r).(*image.RGBA)
+
+	return Frame{
+		Matrix: mat.Clone(),
+		Image:  &image.RGBA{Pix: sub.Pix, Stride: sub.Stride, Rect: image.Rectangle{Max: r.Size()}},
+		Time:   f.Time,
+	}, nil
+}
+
+func capture(at time.Time) (Frame, error) {
+	img, err := video.Capture()
+	if err != nil {
+		return Frame{}, err
+	}
+
+	if img == nil {
+		return Frame{}, fmt.Errorf("capture is empty")
+	}
+
+	m, err := gocv.ImageToMatRGB(img)
+	if err != nil {
+		return Frame{}, err
+	}
+
+	m, img, s, err := normalize(m, img, at)
+	if err != nil {
+		return Frame{}, err
+	}
+
+	return Frame{Matrix: m, Image: img, Time: at, scale: s}, nil
+}
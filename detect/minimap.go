@@ -0,0 +1,58 @@
+package detect
+
+import (
+	"image"
+	"time"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/match"
+	"github.com/pidgy/unitehud/server"
+	"github.com/pidgy/unitehud/team"
+)
+
+func init() {
+	Register(miniMap())
+}
+
+// miniMap tracks the goal zones of both teams on the minimap.
+func miniMap() Detector {
+	return New("Minimap",
+		func() image.Rectangle { return config.Current.MiniMap },
+		func() time.Duration { return time.Second * 2 },
+		nil,
+		func(f Frame) {
+			if f.Idle {
+				return
+			}
+			defer f.Close()
+
+			m, ok := match.MiniMap(f.Matrix, f.Image)
+			if !ok {
+				return
+			}
+
+			server.SetGoals(team.Purple, goals(&m.Purple))
+			server.SetGoals(team.Orange, goals(&m.Orange))
+		},
+	)
+}
+
+func goals(t *match.Tiers) []server.Goal {
+	g := []server.Goal{}
+
+	for _, zone := range config.Current.Goals() {
+		tier := t.Zone(zone)
+		if tier == nil {
+			continue
+		}
+
+		g = append(g, server.Goal{
+			Tier:      zone.Tier,
+			Lane:      zone.Lane,
+			Destroyed: tier.Destroyed,
+			Match:     tier.Match,
+		})
+	}
+
+	return g
+}
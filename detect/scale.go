@@ -0,0 +1,168 @@
+package detect
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sync"
+	"time"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/team"
+)
+
+// scaling is the ratio between the size of the capture and the resolution profile templates are made for.
+// Detection areas are selected on the capture, and are rescaled with it.
+type scaling struct {
+	value     float64
+	size      image.Point // Capture size the scale was detected for.
+	confirmed bool        // Whether the scale was confirmed by matching the match clock.
+	attempted time.Time
+
+	mutex *sync.Mutex
+}
+
+// reference is the resolution profile templates and default areas are made for.
+var reference = image.Pt(1920, 1080)
+
+var scaled = &scaling{
+	value: 1,
+	mutex: &sync.Mutex{},
+}
+
+// Scale returns the detected scale of the capture, and whether it was confirmed by the match clock.
+func Scale() (float64, bool) {
+	scaled.mutex.Lock()
+	defer scaled.mutex.Unlock()
+
+	return scaled.value, scaled.confirmed
+}
+
+// normalize rescales a capture to the reference resolution, closing the original matrix when it is rescaled.
+// Returns the scale the capture was divided by.
+func normalize(m gocv.Mat, img *image.RGBA, at time.Time) (gocv.Mat, *image.RGBA, float64, error) {
+	s := scaled.detect(m, at)
+	if math.Abs(s-1) < .01 {
+		return m, img, 1, nil
+	}
+
+	interpolation := gocv.InterpolationArea
+	if s < 1 {
+		interpolation = gocv.InterpolationLinear
+	}
+
+	resized := gocv.NewMat()
+	gocv.Resize(m, &resized, image.Pt(int(math.Round(float64(m.Cols())/s)), int(math.Round(float64(m.Rows())/s))), 0, 0, interpolation)
+	m.Close()
+
+	i, err := resized.ToImage()
+	if err != nil {
+		resized.Close()
+		return gocv.Mat{}, nil, 0, err
+	}
+
+	rgba, ok := i.(*image.RGBA)
+	if !ok {
+		resized.Close()
+		return gocv.Mat{}, nil, 0, fmt.Errorf("unsupported %T capture", i)
+	}
+
+	return resized, rgba, s, nil
+}
+
+// rescale divides an area of a capture by the scale the capture was divided by.
+func rescale(area image.Rectangle, s float64) image.Rectangle {
+	if s == 0 || s == 1 {
+		return area
+	}
+
+	return image.Rect(
+		int(math.Round(float64(area.Min.X)/s)),
+		int(math.Round(float64(area.Min.Y)/s)),
+		int(math.Round(float64(area.Max.X)/s)),
+		int(math.Round(float64(area.Max.Y)/s)),
+	)
+}
+
+// detect returns the scale of a capture. Captures of a new size are scaled by their size until the scale
+// is confirmed by searching for the match clock at nearby scales.
+func (s *scaling) detect(m gocv.Mat, at time.Time) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	size := image.Pt(m.Cols(), m.Rows())
+	if size != s.size {
+		s.size = size
+		s.value = math.Min(float64(size.X)/float64(reference.X), float64(size.Y)/float64(reference.Y))
+		s.confirmed = false
+		s.attempted = time.Time{}
+
+		notify.System("Scaling %dx%d capture by %.2f", size.X, size.Y, s.value)
+	}
+
+	if s.confirmed || at.Sub(s.attempted) < time.Second*5 {
+		return s.value
+	}
+	s.attempted = at
+
+	value, ok := clockScale(m, s.value)
+	if !ok {
+		return s.value
+	}
+
+	s.value = value
+	s.confirmed = true
+
+	notify.System("Confirmed %dx%d capture scale of %.2f from the match clock", size.X, size.Y, s.value)
+
+	return s.value
+}
+
+// clockScale searches the time area of a capture for the match clock within 10% of a scale, and returns the
+// scale of the best match when any clock digit is found.
+func clockScale(m gocv.Mat, guess float64) (float64, bool) {
+	templates := config.Current.TemplatesTime(team.Time.Name)
+
+	area := config.Current.Time.Intersect(image.Rect(0, 0, m.Cols(), m.Rows()))
+	if area.Empty() {
+		return guess, false
+	}
+
+	region := m.Region(area)
+	defer region.Close()
+
+	mask := gocv.NewMat()
+	defer mask.Close()
+
+	best, max := guess, float32(0)
+
+	// Scales from 90% to 110% of the guess, in steps of 2%.
+	for i := -5; i <= 5; i++ {
+		s := guess * (1 + float64(i)*.02)
+
+		resized := gocv.NewMat()
+		gocv.Resize(region, &resized, image.Pt(int(float64(area.Dx())/s), int(float64(area.Dy())/s)), 0, 0, gocv.InterpolationLinear)
+
+		for _, t := range templates {
+			if t.Empty() || t.Cols() > resized.Cols() || t.Rows() > resized.Rows() {
+				continue
+			}
+
+			result := gocv.NewMat()
+			gocv.MatchTemplate(resized, t.Mat, &result, gocv.TmCcoeffNormed, mask)
+			_, maxv, _, _ := gocv.MinMaxLoc(result)
+			result.Close()
+
+			if maxv > max && !math.IsInf(float64(maxv), 1) {
+				best, max = s, maxv
+			}
+		}
+
+		resized.Close()
+	}
+
+	return best, max >= team.Time.Acceptance
+}
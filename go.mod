@@ -16,7 +16,7 @@ require (
 	github.com/google/go-cmp v0.5.9
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/guptarohit/asciigraph v0.5.5
-	github.com/jezek/xgb v1.1.0 // indirect
+	github.com/jezek/xgb v1.1.0
 	github.com/kbinani/screenshot v0.0.0-20210720154843-7d3a670d8329
 	github.com/klauspost/compress v1.15.6 // indirect
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
@@ -1,6 +1,7 @@
 package gui
 
 import (
+	"fmt"
 	"image"
 	"strings"
 	"time"
@@ -23,6 +24,7 @@ import (
 type areas struct {
 	energy    *area.Widget
 	ko        *area.Widget
+	minimap   *area.Widget
 	objective *area.Widget
 	score     *area.Widget
 	state     *area.Widget
@@ -44,6 +46,7 @@ type capture struct {
 
 type videos struct {
 	device   capture
+	mode     capture
 	window   capture
 	monitor  capture
 	platform capture
@@ -213,6 +216,24 @@ func (g *GUI) areas(collection fonts.Collection) *areas {
 		},
 	}
 
+	a.minimap = &area.Widget{
+		Text:     "Minimap",
+		TextSize: unit.Sp(13),
+		Theme:    collection.Calibri().Theme,
+		Min:      config.Current.MiniMap.Min,
+		Max:      config.Current.MiniMap.Max,
+		NRGBA:    area.Locked,
+		Match:    g.matchMiniMap,
+		Cooldown: time.Second * 2,
+
+		Capture: &area.Capture{
+			Option:      "Minimap",
+			File:        "minimap_area.png",
+			Base:        config.Current.MiniMap,
+			DefaultBase: config.Current.MiniMap,
+		},
+	}
+
 	a.objective = &area.Widget{
 		Text:     "Objectives",
 		TextSize: unit.Sp(13),
@@ -485,6 +506,8 @@ func (g *GUI) videos(text float32) *videos {
 			},
 		},
 		populate: func(videoCaptureDisabledEvent bool) {
+			defer v.mode.populate(videoCaptureDisabledEvent)
+
 			devices := video.Devices()
 
 			// Set the "Disabled" checkbox when device is not active.
@@ -527,6 +550,90 @@ func (g *GUI) videos(text float32) *videos {
 		},
 	}
 
+	v.mode = capture{
+		list: &dropdown.Widget{
+			TextSize: text,
+			Radio:    true,
+			Items: []*dropdown.Item{
+				{
+					Text:    "Default",
+					Checked: widget.Bool{Value: true},
+				},
+			},
+			Callback: func(i *dropdown.Item, _ *dropdown.Widget) {
+				defer v.onevent()
+
+				mode := i.Text
+				if mode == "Default" {
+					mode = ""
+				}
+
+				if mode == config.Current.VideoCaptureMode {
+					return
+				}
+
+				config.Current.VideoCaptureMode = mode
+
+				defer v.mode.populate(false)
+
+				if !device.IsActive() {
+					return
+				}
+
+				video.Close()
+
+				go func() {
+					err := video.Open()
+					if err != nil {
+						g.ToastErrorForce(err)
+
+						config.Current.VideoCaptureMode = ""
+
+						defer v.device.populate(true)
+
+						return
+					}
+				}()
+			},
+		},
+		populate: func(force bool) {
+			modes := []string{"Default"}
+			for _, m := range device.Modes(config.Current.VideoCaptureDevice) {
+				modes = append(modes, fmt.Sprintf("%dx%d", m.X, m.Y))
+			}
+
+			// Modes set in the configuration are listed even when the device does not report them.
+			current := "Default"
+			if config.Current.VideoCaptureMode != "" {
+				current = config.Current.VideoCaptureMode
+
+				listed := false
+				for _, m := range modes {
+					listed = listed || m == current
+				}
+				if !listed {
+					modes = append(modes, current)
+				}
+			}
+
+			same := len(modes) == len(v.mode.list.Items)
+			for i := 0; same && i < len(modes); i++ {
+				same = modes[i] == v.mode.list.Items[i].Text
+			}
+
+			if !same || force {
+				v.mode.list.Items = []*dropdown.Item{}
+				for _, m := range modes {
+					v.mode.list.Items = append(v.mode.list.Items, &dropdown.Item{Text: m})
+				}
+			}
+
+			for _, i := range v.mode.list.Items {
+				i.Checked.Value = i.Text == current
+			}
+		},
+	}
+
 	v.platform = capture{
 		list: &dropdown.Widget{
 			Items: []*dropdown.Item{
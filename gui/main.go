@@ -5,6 +5,7 @@ import (
 	"image"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 
 	"github.com/pidgy/unitehud/config"
 	"github.com/pidgy/unitehud/debug"
+	"github.com/pidgy/unitehud/detect"
 	"github.com/pidgy/unitehud/global"
 	"github.com/pidgy/unitehud/gui/is"
 	"github.com/pidgy/unitehud/gui/visual/button"
@@ -175,6 +177,54 @@ func (g *GUI) main() {
 		},
 	}))
 
+	defer g.Bar.Remove(g.Bar.Add(&button.Widget{
+		Text:        "rev",
+		Font:        g.Bar.Collection.NishikiTeki(),
+		OnHoverHint: func() { g.Bar.ToolTip("Review held scores") },
+		Released:    nrgba.Gold,
+		TextSize:    unit.Sp(12),
+
+		Click: func(this *button.Widget) {
+			defer this.Deactivate()
+
+			g.review()
+		},
+	}))
+
+	defer g.Bar.Remove(g.Bar.Add(&button.Widget{
+		Text:        "key",
+		Font:        g.Bar.Collection.NishikiTeki(),
+		OnHoverHint: func() { g.Bar.ToolTip("Show server control token") },
+		Released:    nrgba.Slate,
+		TextSize:    unit.Sp(12),
+
+		Click: func(this *button.Widget) {
+			defer this.Deactivate()
+
+			g.ToastOK("Server Control Token", config.Current.ServerToken)
+		},
+	}))
+
+	defer g.Bar.Remove(g.Bar.Add(&button.Widget{
+		Text:        "csv",
+		Font:        g.Bar.Collection.NishikiTeki(),
+		OnHoverHint: func() { g.Bar.ToolTip("Export match history") },
+		Released:    nrgba.Seafoam,
+		TextSize:    unit.Sp(12),
+
+		Click: func(this *button.Widget) {
+			defer this.Deactivate()
+
+			n, err := history.ExportAll()
+			if err != nil {
+				notify.Error("Failed to export match history (%v)", err)
+				return
+			}
+
+			notify.System("Exported match history (%d) to \"%s\"", n, history.Exports)
+		},
+	}))
+
 	defer g.Bar.Remove(g.Bar.Add(&button.Widget{
 		Text:        "obs",
 		Font:        g.Bar.Collection.NishikiTeki(),
@@ -405,6 +455,10 @@ func (g *GUI) main() {
 	fpsLabel.Alignment = text.Middle
 	fpsLabel.TextSize = unit.Sp(14)
 
+	scaleLabel := material.H5(g.Bar.Collection.Calibri().Theme, "1.00x")
+	scaleLabel.Alignment = text.Middle
+	scaleLabel.TextSize = unit.Sp(14)
+
 	purpleScoreLabel := material.H5(g.Bar.Collection.Calibri().Theme, "0")
 	purpleScoreLabel.Color = team.Purple.NRGBA.Color()
 	purpleScoreLabel.Alignment = text.Middle
@@ -650,6 +704,19 @@ func (g *GUI) main() {
 											Top:  unit.Dp(18),
 											Left: unit.Dp(float32(gtx.Constraints.Max.X - 135)),
 										}.Layout(gtx, dbgLabel.Layout)
+									} else {
+										scale, confirmed := detect.Scale()
+
+										scaleLabel.Color = nrgba.Slate.Color()
+										if confirmed {
+											scaleLabel.Color = nrgba.Seafoam.Color()
+										}
+
+										scaleLabel.Text = fmt.Sprintf("%.2fx", scale)
+										layout.Inset{
+											Top:  unit.Dp(18),
+											Left: unit.Dp(float32(gtx.Constraints.Max.X - 135)),
+										}.Layout(gtx, scaleLabel.Layout)
 									}
 
 									switch {
@@ -853,3 +920,56 @@ func (g *GUI) main() {
 		}
 	}
 }
+
+// review shows the oldest event held for review. Missed scores ask for the points scored when accepted.
+func (g *GUI) review() {
+	held := state.Reviews()
+	if len(held) == 0 {
+		notify.System("No scores are held for review")
+		return
+	}
+	h := held[0]
+
+	msg := fmt.Sprintf("[%s] %s +%d (%.0f%%)", h.Clock, h.EventType, h.Payload.Points, h.Payload.Confidence*100)
+	if len(held) > 1 {
+		msg = fmt.Sprintf("%s, %d more held", msg, len(held)-1)
+	}
+
+	id := h.ID()
+
+	g.ToastReview("Review", msg, h.Image,
+		func() {
+			if h.Payload.Points != 0 {
+				err := server.Accept(id, nil)
+				if err != nil {
+					notify.Error("Failed to accept event #%d (%v)", id, err)
+				}
+				return
+			}
+
+			go func() {
+				err := g.ToastInput("Points scored", "Points", "", func(text string, _ bool) {
+					points, err := strconv.Atoi(strings.TrimSpace(text))
+					if err != nil {
+						notify.Error("Invalid points \"%s\"", text)
+						return
+					}
+
+					err = server.Accept(id, &points)
+					if err != nil {
+						notify.Error("Failed to accept event #%d (%v)", id, err)
+					}
+				})
+				if err != nil {
+					notify.Error("%v", err)
+				}
+			}()
+		},
+		func() {
+			err := server.Veto(id)
+			if err != nil {
+				notify.Error("Failed to veto event #%d (%v)", id, err)
+			}
+		},
+	)
+}
@@ -97,6 +97,35 @@ func (g *GUI) matchKOs(a *area.Widget) (bool, error) {
 	return r == match.Found, nil
 }
 
+func (g *GUI) matchMiniMap(a *area.Widget) (bool, error) {
+	if !g.Preview {
+		a.NRGBA = area.Locked
+		return false, nil
+	}
+
+	img, err := video.CaptureRect(a.Rectangle())
+	if err != nil {
+		return false, err
+	}
+
+	matrix, err := gocv.ImageToMatRGB(img)
+	if err != nil {
+		return false, err
+	}
+	defer matrix.Close()
+
+	m, ok := match.MiniMap(matrix, img)
+	if !ok {
+		a.NRGBA = area.Miss
+		a.Subtext = strings.Title(match.NotFound.String())
+		return false, nil
+	}
+	a.NRGBA = area.Match
+	a.Subtext = fmt.Sprintf("%d goals", m.Found())
+
+	return true, nil
+}
+
 func (g *GUI) matchObjectives(a *area.Widget) (bool, error) {
 	if !g.Preview {
 		a.NRGBA = area.Locked
@@ -151,6 +151,7 @@ func (g *GUI) projector() {
 					config.Current.Energy = areas.energy.Rectangle()
 					config.Current.Objectives = areas.objective.Rectangle()
 					config.Current.KOs = areas.ko.Rectangle()
+					config.Current.MiniMap = areas.minimap.Rectangle()
 
 					err := config.Current.Save()
 					if err != nil {
@@ -287,6 +288,7 @@ func (g *GUI) projector() {
 			config.Current.Energy = areas.energy.Rectangle()
 			config.Current.Objectives = areas.objective.Rectangle()
 			config.Current.KOs = areas.ko.Rectangle()
+			config.Current.MiniMap = areas.minimap.Rectangle()
 
 			if cached.Eq(&config.Current) {
 				g.Actions <- Refresh
@@ -363,6 +365,7 @@ func (g *GUI) projector() {
 				areas.score.Min, areas.score.Max = config.Current.Scores.Min, config.Current.Scores.Max
 				areas.objective.Min, areas.objective.Max = config.Current.Objectives.Min, config.Current.Objectives.Max
 				areas.ko.Min, areas.ko.Max = config.Current.KOs.Min, config.Current.KOs.Max
+				areas.minimap.Min, areas.minimap.Max = config.Current.MiniMap.Min, config.Current.MiniMap.Max
 
 				// videos.window.populate(true)
 				videos.device.populate(true)
@@ -438,6 +441,10 @@ func (g *GUI) projector() {
 	videoCaptureLabel.Color = nrgba.Highlight.Color()
 	videoCaptureLabel.Font.Weight = 100
 
+	videoCaptureModeLabel := material.Label(g.Bar.Collection.Calibri().Theme, unit.Sp(12), "Capture Mode")
+	videoCaptureModeLabel.Color = nrgba.Highlight.Color()
+	videoCaptureModeLabel.Font.Weight = 100
+
 	monitorLabel := material.Label(g.Bar.Collection.Calibri().Theme, unit.Sp(12), "Monitor")
 	monitorLabel.Color = nrgba.Highlight.Color()
 	monitorLabel.Font.Weight = 100
@@ -657,6 +664,26 @@ func (g *GUI) projector() {
 									}),
 								)
 							}),
+
+							projected.spacer(2, 0),
+
+							layout.Flexed(0.2, func(gtx layout.Context) layout.Dimensions {
+								return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+											return layout.Inset{Top: unit.Dp(5)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+												return videoCaptureModeLabel.Layout(gtx)
+											})
+										})
+									}),
+
+									projected.spacer(0, 1),
+
+									layout.Flexed(.9, func(gtx layout.Context) layout.Dimensions {
+										return videos.mode.list.Layout(gtx, g.Bar.Collection.Calibri().Theme)
+									}),
+								)
+							}),
 							/*
 								projected.spacer(2, 0),
 
@@ -754,6 +781,7 @@ func (g *GUI) projector() {
 					areas.energy,
 					areas.score,
 					areas.ko,
+					areas.minimap,
 					areas.objective,
 					areas.state,
 				} {
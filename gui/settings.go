@@ -12,16 +12,23 @@ import (
 	"gioui.org/widget/material"
 
 	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/detect"
 	"github.com/pidgy/unitehud/fonts"
 	"github.com/pidgy/unitehud/gui/visual"
 	"github.com/pidgy/unitehud/gui/visual/colorpicker"
 	"github.com/pidgy/unitehud/gui/visual/decorate"
+	"github.com/pidgy/unitehud/gui/visual/dropdown"
 	"github.com/pidgy/unitehud/gui/visual/slider"
 	"github.com/pidgy/unitehud/gui/visual/title"
 	"github.com/pidgy/unitehud/notify"
 	"github.com/pidgy/unitehud/nrgba"
 )
 
+type detection struct {
+	list  *dropdown.Widget
+	theme *material.Theme
+}
+
 type section struct {
 	title, description material.LabelStyle
 	warning, widget    visual.Widgeter
@@ -132,6 +139,37 @@ func (s *settings) open(onclose func()) {
 
 	theme.warning = theme.widget.(*colorpicker.Widget).DefaultButton
 
+	detectors := detect.Detectors()
+
+	detectorList := &dropdown.Widget{
+		TextSize: 14,
+		Callback: func(i *dropdown.Item, d *dropdown.Widget) {
+			// Detectors may share a configuration toggle.
+			for n, item := range d.Items {
+				item.Checked.Value = detectors[n].Enabled()
+			}
+		},
+	}
+
+	for _, d := range detectors {
+		d := d
+
+		detectorList.Items = append(detectorList.Items, &dropdown.Item{
+			Text:    d.Name(),
+			Checked: widget.Bool{Value: d.Enabled()},
+			Callback: func(i *dropdown.Item) {
+				d.Enable(i.Checked.Value)
+			},
+		})
+	}
+
+	detections := &section{
+		title:       material.Label(bar.Collection.Calibri().Theme, unit.Sp(15), "Detection"),
+		description: material.Caption(bar.Collection.Calibri().Theme, "Enable or disable each detection"),
+		widget:      &detection{list: detectorList, theme: bar.Collection.Calibri().Theme},
+		warning:     material.Label(bar.Collection.NotoSans().Theme, unit.Sp(11), ""),
+	}
+
 	var ops op.Ops
 
 	s.window.Perform(system.ActionRaise)
@@ -152,6 +190,8 @@ func (s *settings) open(onclose func()) {
 		s.spacer,
 		theme.section,
 		s.spacer,
+		detections.section,
+		s.spacer,
 	}
 
 	for event := range s.window.Events() {
@@ -189,6 +229,10 @@ func (s *settings) open(onclose func()) {
 	}
 }
 
+func (d *detection) Layout(gtx layout.Context) layout.Dimensions {
+	return d.list.Layout(gtx, d.theme)
+}
+
 func (s *settings) fill() layout.FlexChild {
 	return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 		return layout.Dimensions{Size: gtx.Constraints.Max}
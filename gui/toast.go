@@ -23,6 +23,7 @@ import (
 	"github.com/pidgy/unitehud/fonts"
 	"github.com/pidgy/unitehud/gui/visual/button"
 	"github.com/pidgy/unitehud/gui/visual/decorate"
+	"github.com/pidgy/unitehud/gui/visual/screen"
 	"github.com/pidgy/unitehud/gui/visual/title"
 	"github.com/pidgy/unitehud/nrgba"
 )
@@ -508,3 +509,121 @@ func (g *GUI) ToastYesNo(header, msg string, y, n func()) {
 		}
 	}()
 }
+
+// ToastReview shows an event held for review with the capture it was detected in.
+func (g *GUI) ToastReview(header, msg string, img image.Image, accept, veto func()) {
+	if g.toastActive {
+		return
+	}
+
+	g.toastActive = true
+	defer func() { g.toastActive = false }()
+
+	go func() {
+		width, height := unit.Dp(400), unit.Dp(250)
+
+		w := app.NewWindow(
+			app.Title(header),
+			app.Size(width, height),
+			app.MaxSize(width, height),
+			app.MinSize(width, height),
+			app.Decorated(false),
+		)
+
+		bar := title.New(header, fonts.NewCollection(), nil, nil, func() {
+			w.Perform(system.ActionClose)
+		})
+		bar.NoTip = true
+
+		m := material.Label(bar.Collection.Calibri().Theme, toastTextSize, msg)
+		m.Alignment = text.Middle
+
+		capture := &screen.Widget{
+			Image:     img,
+			AutoScale: true,
+		}
+
+		aButton := &button.Widget{
+			Text:            "Accept",
+			TextSize:        unit.Sp(16),
+			Font:            bar.Collection.Calibri(),
+			Pressed:         nrgba.Transparent80,
+			Released:        nrgba.DarkGray,
+			BorderWidth:     unit.Sp(0),
+			Size:            image.Pt(96, 32),
+			NoBorder:        true,
+			TextInsetBottom: -2,
+			Click: func(this *button.Widget) {
+				if accept != nil {
+					accept()
+				}
+				w.Perform(system.ActionClose)
+			},
+		}
+
+		vButton := &button.Widget{
+			Text:            "Veto",
+			TextSize:        unit.Sp(16),
+			Font:            bar.Collection.Calibri(),
+			Pressed:         nrgba.Transparent80,
+			Released:        nrgba.DarkGray,
+			BorderWidth:     unit.Sp(0),
+			NoBorder:        true,
+			Size:            image.Pt(96, 32),
+			TextInsetBottom: -2,
+			Click: func(this *button.Widget) {
+				if veto != nil {
+					veto()
+				}
+				w.Perform(system.ActionClose)
+			},
+		}
+
+		var ops op.Ops
+
+		for e := range w.Events() {
+			switch e := e.(type) {
+			case system.DestroyEvent:
+			case system.FrameEvent:
+				gtx := layout.NewContext(&ops, e)
+
+				bar.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					decorate.ColorBox(gtx, gtx.Constraints.Max, nrgba.NRGBA(config.Current.Theme.BackgroundAlt))
+
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(layout.Spacer{Height: 10}.Layout),
+
+						layout.Flexed(.5, capture.Layout),
+
+						layout.Flexed(.25, func(gtx layout.Context) layout.Dimensions {
+							decorate.Label(&m, m.Text)
+							return m.Layout(gtx)
+						}),
+
+						layout.Flexed(.25, func(gtx layout.Context) layout.Dimensions {
+							return layout.Flex{Axis: layout.Horizontal}.Layout(
+								gtx,
+								layout.Rigid(layout.Spacer{Width: 5}.Layout),
+								layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+									return layout.Center.Layout(gtx, aButton.Layout)
+								}),
+								layout.Rigid(layout.Spacer{Width: 1}.Layout),
+								layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+									return layout.Center.Layout(gtx, vButton.Layout)
+								}),
+								layout.Rigid(layout.Spacer{Width: 5}.Layout),
+							)
+						}),
+
+						layout.Rigid(layout.Spacer{Height: 2}.Layout),
+					)
+				})
+
+				w.Perform(system.ActionCenter)
+				w.Perform(system.ActionRaise)
+				w.Invalidate()
+				e.Frame(gtx.Ops)
+			}
+		}
+	}()
+}
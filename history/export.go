@@ -0,0 +1,182 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exports is the directory match exports are written to.
+var Exports = "exports"
+
+var (
+	matchesCSV = []string{
+		"id", "time", "duration", "profile", "platform", "result",
+		"purple_score", "purple_kos", "purple_regielekis", "purple_regices", "purple_regirocks", "purple_registeels", "purple_rayquazas",
+		"orange_score", "orange_kos", "orange_regielekis", "orange_regices", "orange_regirocks", "orange_registeels", "orange_rayquazas",
+		"self_score",
+	}
+
+	eventsCSV = []string{
+		"match", "type", "name", "time", "clock", "value", "vetoed", "verified",
+		"team", "objective", "points", "streak", "detector", "confidence", "frame",
+	}
+)
+
+// Export appends a match to exports/matches.csv, its events to exports/events.csv, and writes the entire
+// match to exports/match-{id}.json.
+func Export(m Match) error {
+	err := os.MkdirAll(Exports, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = appendCSV("matches.csv", matchesCSV, [][]string{m.row()})
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	for _, e := range m.Events {
+		rows = append(rows, e.row(m.ID))
+	}
+
+	err = appendCSV("events.csv", eventsCSV, rows)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(m, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(Exports, fmt.Sprintf("match-%d.json", m.ID)), raw, 0644)
+}
+
+// ExportAll replaces any previous exports with every match stored in the history database.
+func ExportAll() (int, error) {
+	mutex.RLock()
+	matches := append([]Match{}, history...)
+	mutex.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(Exports, "match-*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	files = append(files, filepath.Join(Exports, "matches.csv"), filepath.Join(Exports, "events.csv"))
+
+	for _, file := range files {
+		err := os.Remove(file)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+	}
+
+	for _, m := range matches {
+		err := Export(m)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(matches), nil
+}
+
+// appendCSV appends rows to a CSV export, writing its header first. Exports with different columns, written
+// by previous versions, are renamed to {name}.{unix}.old and replaced.
+func appendCSV(name string, header []string, rows [][]string) error {
+	file := filepath.Join(Exports, name)
+
+	exists, err := columns(file, header)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+
+	if !exists {
+		err = w.Write(header)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// columns returns true if a CSV export exists with a header, renaming it when the header differs.
+func columns(file string, header []string) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	existing, err := csv.NewReader(f).Read()
+	f.Close()
+
+	if err == nil && strings.Join(existing, ",") == strings.Join(header, ",") {
+		return true, nil
+	}
+
+	return false, os.Rename(file, fmt.Sprintf("%s.%d.old", file, time.Now().Unix()))
+}
+
+func (e Event) row(match int64) []string {
+	return []string{
+		strconv.FormatInt(match, 10),
+		strconv.Itoa(e.Type),
+		e.Name,
+		e.Time.Format(time.RFC3339),
+		e.Clock,
+		strconv.Itoa(e.Value),
+		strconv.FormatBool(e.Vetoed),
+		strconv.FormatBool(e.Verified),
+		e.Team,
+		e.Objective,
+		strconv.Itoa(e.Points),
+		strconv.FormatBool(e.Streak),
+		e.Detector,
+		strconv.FormatFloat(float64(e.Confidence), 'f', 3, 32),
+		strconv.FormatInt(e.Frame, 10),
+	}
+}
+
+func (m Match) row() []string {
+	row := []string{
+		strconv.FormatInt(m.ID, 10),
+		m.Time.Format(time.RFC3339),
+		strconv.Itoa(m.Duration),
+		m.Profile,
+		m.Platform,
+		m.Result,
+	}
+
+	for _, t := range []Team{m.Purple, m.Orange} {
+		for _, n := range []int{t.Score, t.KOs, t.Regielekis, t.Regices, t.Regirocks, t.Registeels, t.Rayquazas} {
+			row = append(row, strconv.Itoa(n))
+		}
+	}
+
+	return append(row, strconv.Itoa(m.Self.Score))
+}
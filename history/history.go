@@ -1,51 +1,248 @@
 package history
 
 import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/pidgy/unitehud/notify"
 	"github.com/pidgy/unitehud/nrgba"
+	"github.com/pidgy/unitehud/state"
 )
 
-type match struct {
-	purple, orange, self int
-	time.Time
+const (
+	Win  = "win"
+	Loss = "loss"
+	Tie  = "tie"
+)
+
+// File is the database of finished matches, one JSON encoded match per line.
+var File = "unitehud.history"
+
+type Match struct {
+	ID       int64     `json:"id"`
+	Time     time.Time `json:"time"`
+	Duration int       `json:"duration"` // Seconds between the match starting and ending.
+	Profile  string    `json:"profile"`
+	Platform string    `json:"platform"`
+	Result   string    `json:"result"`
+	Purple   Team      `json:"purple"`
+	Orange   Team      `json:"orange"`
+	Self     Self      `json:"self"`
+	Events   []Event   `json:"events,omitempty"`
+}
+
+type Team struct {
+	Score      int `json:"score"`
+	KOs        int `json:"kos"`
+	Regielekis int `json:"regielekis"`
+	Regices    int `json:"regices"`
+	Regirocks  int `json:"regirocks"`
+	Registeels int `json:"registeels"`
+	Rayquazas  int `json:"rayquazas"`
 }
 
-var history = []match{}
+type Self struct {
+	Score int `json:"score"`
+}
+
+type Event struct {
+	Type     int       `json:"type"`
+	Name     string    `json:"name"`
+	Time     time.Time `json:"time"`
+	Clock    string    `json:"clock"`
+	Value    int       `json:"value"`
+	Vetoed   bool      `json:"vetoed"`
+	Verified bool      `json:"verified"`
+
+	state.Payload
+}
 
-func Add(purple, orange, self int) {
-	history = append(history, match{
-		orange: orange,
-		purple: purple,
-		self:   self,
-		Time:   time.Now(),
-	})
+// Filter narrows the matches returned by Matches, zero values are ignored.
+type Filter struct {
+	Since  time.Time
+	Until  time.Time
+	Result string
+}
+
+var (
+	history = []Match{}
+	mutex   = &sync.RWMutex{}
+)
+
+// Open loads every match stored in the history database.
+func Open() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	f, err := os.Open(File)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	history = []Match{}
+
+	d := json.NewDecoder(f)
+	for {
+		m := Match{}
+
+		err := d.Decode(&m)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			notify.Warn("History database %s is corrupted after %d matches (%v)", File, len(history), err)
+			break
+		}
+
+		history = append(history, m)
+	}
+
+	notify.System("Loaded %d matches from %s", len(history), File)
+
+	return nil
+}
+
+// Add stores a finished match in the history database, and returns the match with its ID and result.
+func Add(m Match) (Match, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	m.ID = 1
+	if len(history) > 0 {
+		m.ID = history[len(history)-1].ID + 1
+	}
+
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+
+	m.Result = result(m.Purple.Score, m.Orange.Score)
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return m, err
+	}
+
+	f, err := os.OpenFile(File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(raw, '\n'))
+	if err != nil {
+		return m, err
+	}
+
+	history = append(history, m)
+
+	return m, nil
+}
+
+// Events converts a state event history, ordered newest to oldest, into an oldest to newest match timeline.
+func Events(events []*state.Event) []Event {
+	e := []Event{}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		e = append(e, Event{
+			Type:     events[i].EventType.Int(),
+			Name:     events[i].EventType.String(),
+			Time:     events[i].Time,
+			Clock:    events[i].Clock,
+			Value:    events[i].Value,
+			Vetoed:   events[i].Vetoed,
+			Verified: events[i].Verified,
+			Payload:  events[i].Payload,
+		})
+	}
+
+	return e
+}
+
+// Get returns the match with the given ID.
+func Get(id int64) (Match, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	i := sort.Search(len(history), func(i int) bool { return history[i].ID >= id })
+	if i == len(history) || history[i].ID != id {
+		return Match{}, false
+	}
+
+	return history[i], true
+}
+
+// Matches returns every stored match accepted by f, ordered from newest to oldest.
+func Matches(f Filter) []Match {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	matches := []Match{}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		m := history[i]
+
+		switch {
+		case !f.Since.IsZero() && m.Time.Before(f.Since):
+			continue
+		case !f.Until.IsZero() && m.Time.After(f.Until):
+			continue
+		case f.Result != "" && f.Result != m.Result:
+			continue
+		}
+
+		matches = append(matches, m)
+	}
+
+	return matches
 }
 
 func Dump() {
-	if len(history) == 0 {
+	matches := Matches(Filter{Since: time.Now().Add(-time.Hour * 24)})
+	if len(matches) == 0 {
 		notify.Warn("No recent game history to display...")
 		return
 	}
 
 	notify.System("Match History")
 
-	for _, h := range history {
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+
 		color := nrgba.Green
 		result := ""
-		switch {
-		case h.purple > h.orange:
+		switch m.Result {
+		case Win:
 			result = "Win »"
 			color = nrgba.Green
-		case h.orange > h.purple:
+		case Loss:
 			result = "Loss «"
 			color = nrgba.DarkRed
-		case h.orange == h.purple:
+		case Tie:
 			result = "Tie ¤"
 			color = nrgba.Yellow
 		}
 
-		notify.Append(color, "(%s) %s %d - %d - %d", h.Time.Format(time.Kitchen), result, h.purple, h.orange, h.self)
+		notify.Append(color, "(%s) %s %d - %d - %d", m.Time.Format(time.Kitchen), result, m.Purple.Score, m.Orange.Score, m.Self.Score)
+	}
+}
+
+// result returns the result of a match from the perspective of the purple team.
+func result(purple, orange int) string {
+	switch {
+	case purple > orange:
+		return Win
+	case orange > purple:
+		return Loss
+	default:
+		return Tie
 	}
 }
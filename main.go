@@ -12,6 +12,7 @@ import (
 	"github.com/pidgy/unitehud/global"
 	"github.com/pidgy/unitehud/gui"
 	"github.com/pidgy/unitehud/gui/visual/title"
+	"github.com/pidgy/unitehud/history"
 	"github.com/pidgy/unitehud/notify"
 	"github.com/pidgy/unitehud/process"
 	"github.com/pidgy/unitehud/server"
@@ -92,11 +93,20 @@ func main() {
 		kill(err)
 	}
 
+	err = history.Open()
+	if err != nil {
+		notify.Error("Failed to open match history (%v)", err)
+	}
+
 	err = video.Open()
 	if err != nil {
 		notify.Error("Failed to open Video Capture Device (%v)", err)
 	}
 
+	if config.Current.ServerAddress != "" {
+		server.Address = config.Current.ServerAddress
+	}
+
 	err = server.Listen()
 	if err != nil {
 		notify.Error("Failed to start UniteHUD server (%v)", err)
@@ -117,16 +127,7 @@ func main() {
 	go detect.Preview()
 	// go detect.Window()
 
-	go detect.Clock()
-	go detect.Energy()
-	go detect.PressButtonToScore()
-	go detect.Defeated()
-	go detect.KOs()
-	go detect.Objectives()
-	go detect.States()
-	go detect.Scores(team.Purple.Name)
-	go detect.Scores(team.Orange.Name)
-	go detect.Scores(team.First.Name)
+	detect.Start()
 	go update.Check()
 
 	go func() {
@@ -227,6 +228,17 @@ func main() {
 		}
 	}()
 
+	go func() {
+		for action := range server.Actions {
+			switch action {
+			case server.Start:
+				gui.Window.Actions <- gui.Start
+			case server.Stop:
+				gui.Window.Actions <- gui.Stop
+			}
+		}
+	}()
+
 	go signals()
 
 	notify.System("Initialized")
@@ -0,0 +1,341 @@
+package match
+
+import (
+	"image"
+	"math"
+	"sort"
+	"sync"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/stats"
+	"github.com/pidgy/unitehud/template"
+)
+
+// Digit is a digit classified from an area, ordered from left to right.
+type Digit struct {
+	Value      int
+	Confidence float32
+	image.Rectangle
+}
+
+// classifier is a nearest-neighbour classifier trained from the digit templates of a team.
+type classifier struct {
+	templates []*template.Template
+	samples   []sample
+	size      image.Point // Median size of a digit.
+}
+
+type sample struct {
+	*template.Template
+	feature []float32
+}
+
+// feature is the size digits are scaled to before they are compared.
+var feature = image.Pt(12, 18)
+
+var (
+	classifiers      = map[string]*classifier{}
+	classifiersMutex = &sync.Mutex{}
+)
+
+// Digits segments an area into connected components, and classifies each component as the digit of the
+// nearest template. Components that do not resemble a digit by at least acceptance are still returned with
+// their confidence, so callers can reject the area rather than read the remaining digits as one value.
+func Digits(matrix gocv.Mat, templates []*template.Template, acceptance float32) []Digit {
+	if len(templates) == 0 || matrix.Empty() {
+		return nil
+	}
+
+	c := classify(templates)
+	if len(c.samples) == 0 {
+		return nil
+	}
+
+	bin := binary(matrix)
+	defer bin.Close()
+
+	digits := []Digit{}
+
+	for _, r := range c.segment(bin) {
+		crop := bin.Region(r)
+		f := features(crop)
+		crop.Close()
+
+		s, confidence := c.nearest(f)
+
+		go stats.Frequency(s.Truncated(), confidence)
+
+		if confidence >= acceptance {
+			go stats.Average(s.Truncated(), confidence)
+			go stats.Count(s.Truncated())
+		}
+
+		digits = append(digits, Digit{Value: s.Value, Confidence: confidence, Rectangle: r})
+	}
+
+	return digits
+}
+
+// uncertain returns true if any digit resembles its template by less than acceptance.
+func uncertain(digits []Digit, acceptance float32) bool {
+	for _, d := range digits {
+		if d.Confidence < acceptance {
+			return true
+		}
+	}
+	return false
+}
+
+// values returns the values of the first n digits padded with -1, optionally skipping leading zeros.
+func values(digits []Digit, n int, zeros bool) []int {
+	v := []int{}
+
+	for _, d := range digits {
+		if len(v) == n {
+			break
+		}
+
+		if !zeros && len(v) == 0 && d.Value == 0 {
+			continue
+		}
+
+		v = append(v, d.Value)
+	}
+
+	for len(v) < n {
+		v = append(v, -1)
+	}
+
+	return v
+}
+
+// binary returns a thresholded copy of an area where digits are white, assuming digits cover less of the
+// area than their background.
+func binary(matrix gocv.Mat) gocv.Mat {
+	gray := gocv.NewMat()
+	defer gray.Close()
+
+	if matrix.Channels() > 1 {
+		gocv.CvtColor(matrix, &gray, gocv.ColorBGRToGray)
+	} else {
+		matrix.CopyTo(&gray)
+	}
+
+	bin := gocv.NewMat()
+	gocv.Threshold(gray, &bin, 0, 255, gocv.ThresholdBinary|gocv.ThresholdOtsu)
+
+	if gocv.CountNonZero(bin) > bin.Rows()*bin.Cols()/2 {
+		gocv.BitwiseNot(bin, &bin)
+	}
+
+	return bin
+}
+
+// classify returns the classifier trained from templates, training a new classifier when the templates
+// have been reloaded.
+func classify(templates []*template.Template) *classifier {
+	classifiersMutex.Lock()
+	defer classifiersMutex.Unlock()
+
+	key := templates[0].Team.Name + "/" + templates[0].Category
+
+	c, ok := classifiers[key]
+	if ok && c.trained(templates) {
+		return c
+	}
+
+	c = &classifier{templates: templates}
+
+	sizes := []image.Point{}
+
+	for _, t := range templates {
+		if t.Empty() || t.Value < 0 || t.Value > 9 {
+			continue
+		}
+
+		bin := binary(t.Mat)
+
+		r := glyph(bin)
+		if r.Empty() {
+			bin.Close()
+			continue
+		}
+
+		crop := bin.Region(r)
+		c.samples = append(c.samples, sample{Template: t, feature: features(crop)})
+		crop.Close()
+		bin.Close()
+
+		sizes = append(sizes, r.Size())
+	}
+
+	if len(sizes) > 0 {
+		sort.Slice(sizes, func(i, j int) bool { return sizes[i].Y < sizes[j].Y })
+		c.size.Y = sizes[len(sizes)/2].Y
+
+		sort.Slice(sizes, func(i, j int) bool { return sizes[i].X < sizes[j].X })
+		c.size.X = sizes[len(sizes)/2].X
+	}
+
+	classifiers[key] = c
+
+	return c
+}
+
+// components returns the bounding rectangle of every connected component of a binary area.
+func components(bin gocv.Mat) []image.Rectangle {
+	labels, stat, centroids := gocv.NewMat(), gocv.NewMat(), gocv.NewMat()
+	defer labels.Close()
+	defer stat.Close()
+	defer centroids.Close()
+
+	n := gocv.ConnectedComponentsWithStats(bin, &labels, &stat, &centroids)
+
+	rects := []image.Rectangle{}
+
+	// Label 0 is the background.
+	for i := 1; i < n; i++ {
+		x := int(stat.GetIntAt(i, int(gocv.CC_STAT_LEFT)))
+		y := int(stat.GetIntAt(i, int(gocv.CC_STAT_TOP)))
+		w := int(stat.GetIntAt(i, int(gocv.CC_STAT_WIDTH)))
+		h := int(stat.GetIntAt(i, int(gocv.CC_STAT_HEIGHT)))
+
+		rects = append(rects, image.Rect(x, y, x+w, y+h))
+	}
+
+	return rects
+}
+
+// features scales a binary digit to the feature size, preserving its aspect ratio, and returns the
+// normalized pixels so the dot product of two features is their correlation coefficient.
+func features(crop gocv.Mat) []float32 {
+	w := int(math.Round(float64(crop.Cols()) * float64(feature.Y) / float64(crop.Rows())))
+	if w < 1 {
+		w = 1
+	}
+	if w > feature.X {
+		w = feature.X
+	}
+
+	scaled := gocv.NewMat()
+	defer scaled.Close()
+
+	gocv.Resize(crop, &scaled, image.Pt(w, feature.Y), 0, 0, gocv.InterpolationArea)
+
+	f := make([]float32, feature.X*feature.Y)
+
+	inset := (feature.X - w) / 2
+	for y := 0; y < feature.Y; y++ {
+		for x := 0; x < w; x++ {
+			f[y*feature.X+x+inset] = float32(scaled.GetUCharAt(y, x)) / 255
+		}
+	}
+
+	mean := float32(0)
+	for _, v := range f {
+		mean += v
+	}
+	mean /= float32(len(f))
+
+	norm := float32(0)
+	for i := range f {
+		f[i] -= mean
+		norm += f[i] * f[i]
+	}
+
+	if norm == 0 {
+		return f
+	}
+
+	norm = float32(math.Sqrt(float64(norm)))
+	for i := range f {
+		f[i] /= norm
+	}
+
+	return f
+}
+
+// glyph returns the bounding rectangle of the components of a template that are tall enough to be part of
+// its digit.
+func glyph(bin gocv.Mat) image.Rectangle {
+	r := image.Rectangle{}
+
+	for _, c := range components(bin) {
+		if c.Dy() < bin.Rows()/2 {
+			continue
+		}
+		r = r.Union(c)
+	}
+
+	return r
+}
+
+// nearest returns the sample most correlated with a feature.
+func (c *classifier) nearest(f []float32) (sample, float32) {
+	best, max := c.samples[0], float32(-1)
+
+	for _, s := range c.samples {
+		dot := float32(0)
+		for i := range f {
+			dot += f[i] * s.feature[i]
+		}
+
+		if dot > max {
+			best, max = s, dot
+		}
+	}
+
+	return best, max
+}
+
+// segment returns the areas of a binary area that are sized like a digit, from left to right. Components
+// that overlap horizontally are merged, and components as wide as several digits are split evenly.
+func (c *classifier) segment(bin gocv.Mat) []image.Rectangle {
+	rects := components(bin)
+	sort.Slice(rects, func(i, j int) bool { return rects[i].Min.X < rects[j].Min.X })
+
+	merged := []image.Rectangle{}
+	for _, r := range rects {
+		last := len(merged) - 1
+		if last >= 0 && r.Min.X < merged[last].Max.X {
+			merged[last] = merged[last].Union(r)
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	digits := []image.Rectangle{}
+
+	for _, r := range merged {
+		if r.Dy()*10 < c.size.Y*6 || r.Dy()*10 > c.size.Y*14 {
+			continue
+		}
+
+		n := 1
+		if c.size.X > 0 && r.Dx()*10 > c.size.X*16 {
+			n = int(math.Round(float64(r.Dx()) / float64(c.size.X)))
+		}
+
+		for i := 0; i < n; i++ {
+			digits = append(digits, image.Rect(r.Min.X+r.Dx()*i/n, r.Min.Y, r.Min.X+r.Dx()*(i+1)/n, r.Max.Y))
+		}
+	}
+
+	return digits
+}
+
+// trained returns true if the classifier was trained from templates.
+func (c *classifier) trained(templates []*template.Template) bool {
+	if len(c.templates) != len(templates) {
+		return false
+	}
+
+	for i := range templates {
+		if c.templates[i] != templates[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+package match
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/team"
+	"github.com/pidgy/unitehud/template"
+)
+
+func TestUncertain(t *testing.T) {
+	for _, c := range []struct {
+		confidences []float32
+		uncertain   bool
+	}{
+		{nil, false},
+		{[]float32{.9, .85}, false},
+		{[]float32{.9, .5}, true},
+		{[]float32{.2, .9, .9}, true},
+	} {
+		digits := []Digit{}
+		for _, conf := range c.confidences {
+			digits = append(digits, Digit{Confidence: conf})
+		}
+
+		if uncertain(digits, .8) != c.uncertain {
+			t.Errorf("%v: expected uncertain %t", c.confidences, c.uncertain)
+		}
+	}
+}
+
+// BenchmarkDigits reads a purple score from the regression corpus with the digit classifier.
+func BenchmarkDigits(b *testing.B) {
+	matrix, templates := points(b)
+	defer matrix.Close()
+
+	for i := 0; i < b.N; i++ {
+		Digits(matrix, templates, team.Purple.Acceptance)
+	}
+}
+
+// BenchmarkTemplatePasses reads the same score by running every digit template over the area once per
+// digit, as scores were read before the digit classifier.
+func BenchmarkTemplatePasses(b *testing.B) {
+	matrix, templates := points(b)
+	defer matrix.Close()
+
+	result := gocv.NewMat()
+	defer result.Close()
+
+	for i := 0; i < b.N; i++ {
+		for digit := 0; digit < 2; digit++ {
+			for _, t := range templates {
+				if t.Mat.Rows() > matrix.Rows() || t.Mat.Cols() > matrix.Cols() {
+					continue
+				}
+
+				gocv.MatchTemplate(matrix, t.Mat, &result, gocv.TmCcoeffNormed, t.Mask)
+				gocv.MinMaxLoc(result)
+			}
+		}
+	}
+}
+
+// points loads the player profile and returns a purple score from the regression corpus, with the point
+// templates of purple.
+func points(b *testing.B) (gocv.Mat, []*template.Template) {
+	b.Helper()
+
+	assets, err := filepath.Abs(filepath.Join("..", "assets"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	file, err := filepath.Abs(filepath.Join("..", "regression", "testdata", "corpus", "switch", "points-purple-38.png"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	config.AssetsDirectory = assets
+	b.Cleanup(func() { config.AssetsDirectory = "" })
+
+	// Configuration files are saved to the working directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.Chdir(wd) })
+
+	err = os.Chdir(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	err = config.Load(config.ProfilePlayer)
+	if err != nil {
+		b.Fatal(err)
+	}
+	config.Current.SetPlatform(config.PlatformSwitch)
+
+	matrix := gocv.IMRead(file, gocv.IMReadColor)
+	if matrix.Empty() {
+		b.Fatalf("failed to read %s", file)
+	}
+
+	templates := config.Current.TemplatesPoints(team.Purple.Name)
+
+	// Train the classifier before timing.
+	Digits(matrix, templates, team.Purple.Acceptance)
+
+	b.ResetTimer()
+
+	return matrix, templates
+}
@@ -3,7 +3,6 @@ package match
 import (
 	"image"
 	"image/color"
-	"math"
 	"strconv"
 
 	"gocv.io/x/gocv"
@@ -11,7 +10,6 @@ import (
 	"github.com/pidgy/unitehud/config"
 	"github.com/pidgy/unitehud/rgba"
 	"github.com/pidgy/unitehud/state"
-	"github.com/pidgy/unitehud/stats"
 	"github.com/pidgy/unitehud/team"
 	"github.com/pidgy/unitehud/template"
 )
@@ -35,94 +33,15 @@ func AsAeosImage(mat gocv.Mat, points int) (image.Image, error) {
 	return crop, nil
 }
 
-// Energy avoids the walking inset method in previous versions which fails for duplicate values.
-// Instead, Energy handles duplicate values by removing matched areas to avoid detection.
+// Energy classifies each digit once, duplicate values are separate components of the area.
 func Energy(matrix gocv.Mat, img image.Image) (Result, []int, int) {
-	mins := []int{math.MaxInt32, math.MaxInt32}
-	maxs := []float32{0, 0}
-	points := []int{-1, -1}
-	matched := []image.Rectangle{{}, {}}
+	digits := Digits(matrix, config.Current.TemplatesPoints(team.Energy.Name), team.Energy.Acceptance)
 
-	templates := config.Current.TemplatesPoints(team.Energy.Name)
-
-	region := matrix.Clone()
-
-	for round := 0; round < len(points); round++ {
-		results := make([]gocv.Mat, len(templates))
-
-		for i, template := range templates {
-			if template.Mat.Cols() > region.Cols() || template.Mat.Rows() > region.Rows() {
-				return Invalid, points, -1
-			}
-
-			mat := gocv.NewMat()
-			defer mat.Close()
-
-			results[i] = mat
-
-			gocv.MatchTemplate(region, template.Mat, &mat, gocv.TmCcoeffNormed, template.Mask)
-		}
-
-		for i := range results {
-			if results[i].Empty() {
-				continue
-			}
-
-			_, maxv, _, maxp := gocv.MinMaxLoc(results[i])
-			if math.IsInf(float64(maxv), 1) {
-				continue
-			}
-
-			go stats.Frequency(templates[i].Truncated(), maxv)
-
-			if maxv >= team.Energy.Acceptance {
-				go stats.Average(templates[i].Truncated(), maxv)
-				go stats.Count(templates[i].Truncated())
-
-				// No sorting comparison exists yet, proceed.
-				if mins[round] == 0 {
-					break
-				}
-
-				// Keep the leftmost value, always.
-				if maxp.X > mins[round] {
-					continue
-				}
-				// Keep the best match for locations.
-				if maxp.X == mins[round] && maxv < maxs[round] {
-					continue
-				}
-
-				// First round we care more about the smallest X value, 0 is trumped.
-				// Second round we care more about the highest acceptance value.
-				switch round {
-				case 0:
-				case 1:
-					if maxv < maxs[round] {
-						continue
-					}
-				}
-
-				// Once were here we should have the smallest X value (leftmost).
-				maxs[round] = maxv
-				mins[round] = maxp.X
-				points[round] = templates[i].Value
-				matched[round] = image.Rect(maxp.X, maxp.Y, maxp.X+templates[i].Cols(), maxp.Y+templates[i].Rows())
-			}
-
-			go stats.Frequency(templates[i].Truncated(), maxv)
-		}
-
-		if points[round] == -1 {
-			continue
-		}
-
-		gocv.Rectangle(&region, matched[round], rgba.Black.Color(), -1)
-	}
+	points := values(digits, 2, true)
 
 	switch {
-	case points[0] == -1 && points[1] == 0:
-		return Found, points, points[1]
+	case uncertain(digits, team.Energy.Acceptance):
+		return Invalid, points, -1
 	case points[0]+points[1] == -2: // Zero digits.
 		return NotFound, points, -1
 	case points[0]+points[1] == -1: // Single digit, can only be zero.
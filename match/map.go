@@ -1,18 +1,19 @@
 package match
 
 import (
-	"fmt"
 	"image"
+	"math"
+
+	"gocv.io/x/gocv"
 
 	"github.com/pidgy/unitehud/config"
-	"github.com/pidgy/unitehud/notify"
 	"github.com/pidgy/unitehud/rgba"
 	"github.com/pidgy/unitehud/state"
 	"github.com/pidgy/unitehud/stats"
 	"github.com/pidgy/unitehud/team"
-	"gocv.io/x/gocv"
 )
 
+// Tier is a goal zone found on the minimap. Goal zones that were not found have no match.
 type Tier struct {
 	Destroyed bool
 	image.Point
@@ -54,9 +55,17 @@ type Map struct {
 	Objectives
 }
 
-func MiniMap(matrix gocv.Mat, img *image.RGBA) (Map, bool) {
-	return Map{}, false
+const (
+	// goalAcceptance is the minimum match of a goal zone marker.
+	goalAcceptance = .9
+	// zoneDistance is the maximum distance between a goal zone marker and its zone, as a fraction of the
+	// minimap width.
+	zoneDistance = .12
+)
 
+// MiniMap finds the open and destroyed goal zones of both teams on the minimap, and returns false when no
+// goal zones were found.
+func MiniMap(matrix gocv.Mat, img *image.RGBA) (Map, bool) {
 	g, ok := goals(matrix, img)
 	if !ok {
 		return Map{}, false
@@ -73,66 +82,127 @@ func MiniMap(matrix gocv.Mat, img *image.RGBA) (Map, bool) {
 	}, true
 }
 
+// Found returns the number of goal zones found on the minimap, open or destroyed.
+func (m Map) Found() int {
+	n := 0
+
+	for _, t := range []*Tiers{&m.Purple, &m.Orange} {
+		for _, g := range config.Current.Goals() {
+			z := t.Zone(g)
+			if z != nil && z.Match > 0 {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// Zone returns the tier of a goal zone, or nil for goal zones without a tier.
+func (t *Tiers) Zone(g config.Goal) *Tier {
+	switch {
+	case g.Tier == 1 && g.Lane == "top":
+		return &t.Tier1.Top
+	case g.Tier == 1 && g.Lane == "bottom":
+		return &t.Tier1.Bottom
+	case g.Tier == 2 && g.Lane == "top":
+		return &t.Tier2.Top
+	case g.Tier == 2 && g.Lane == "bottom":
+		return &t.Tier2.Bottom
+	case g.Tier == 3:
+		return &t.Tier3.Middle
+	}
+	return nil
+}
+
 func objectives(matrix gocv.Mat, img *image.RGBA) (Objectives, bool) {
 	return Objectives{}, true
 }
 
+// goals matches every goal zone marker, assigning each to the nearest goal zone of its team. A goal zone
+// matched by more than one marker keeps the best match.
 func goals(matrix gocv.Mat, img *image.RGBA) (Goals, bool) {
-	templates := config.Current.TemplatesGoals(team.Game.Name)
+	g := Goals{}
+	found := false
 
-	purple := []Tier{}
-	orange := []Tier{}
+	size := image.Pt(matrix.Cols(), matrix.Rows())
 
-	for x := 0; x < 5; x++ {
-		results := []gocv.Mat{}
+	for _, template := range config.Current.TemplatesGoals(team.Game.Name) {
+		if template.Cols() > size.X || template.Rows() > size.Y {
+			continue
+		}
 
-		for _, template := range templates {
-			mat := gocv.NewMat()
-			defer mat.Close()
+		tiers, mirrored, destroyed := &g.Purple, false, false
+
+		switch state.EventType(template.Value) {
+		case state.PurpleBaseOpen:
+		case state.PurpleBaseClosed:
+			destroyed = true
+		case state.OrangeBaseOpen:
+			tiers, mirrored = &g.Orange, true
+		case state.OrangeBaseClosed:
+			tiers, mirrored, destroyed = &g.Orange, true, true
+		default:
+			continue
+		}
 
-			results = append(results, mat)
+		result := gocv.NewMat()
 
-			gocv.MatchTemplate(matrix, template.Mat, &mat, gocv.TmCcoeffNormed, mask)
-		}
+		gocv.MatchTemplate(matrix, template.Mat, &result, gocv.TmCcoeffNormed, mask)
+
+		marker := image.Pt(template.Cols(), template.Rows())
+
+		for n := 0; n < len(config.Current.Goals()) && !result.Empty(); n++ {
+			_, maxv, _, maxp := gocv.MinMaxLoc(result)
 
-		for i := range results {
-			if results[i].Empty() {
-				notify.SystemWarn("Empty result for %s", templates[i].Truncated())
-				continue
+			go stats.Frequency(template.Truncated(), maxv)
+
+			if maxv < goalAcceptance || math.IsInf(float64(maxv), 1) {
+				break
 			}
 
-			_, maxv, _, maxp := gocv.MinMaxLoc(results[i])
-			if maxv >= .9 {
-				go stats.Average(templates[i].Truncated(), maxv)
-				go stats.Count(templates[i].Truncated())
-
-				switch e := state.EventType(templates[i].Value); e {
-				case state.PurpleBaseOpen:
-					println("purple base open: ", maxp.String())
-					purple = append(purple, Tier{Point: maxp, Match: maxv})
-					gocv.Rectangle(&matrix, image.Rectangle{maxp, maxp.Add(image.Pt(25, 25))}, rgba.Black.Color(), -1)
-					gocv.PutText(&matrix, fmt.Sprintf("%.1f%%", maxv*100), maxp, gocv.FontHersheyPlain, 1, rgba.White.Color(), 2)
-				case state.PurpleBaseClosed:
-					println("purple base closed: ", maxp.String())
-					purple = append(purple, Tier{Destroyed: true, Point: maxp, Match: maxv})
-					gocv.Rectangle(&matrix, image.Rectangle{maxp, maxp.Add(image.Pt(25, 25))}, rgba.Black.Color(), -1)
-					gocv.PutText(&matrix, fmt.Sprintf("%.1f%%", maxv*100), maxp, gocv.FontHersheyPlain, 1, rgba.White.Color(), 2)
-				case state.OrangeBaseOpen:
-					println("orange base open: ", maxp.String())
-					orange = append(orange, Tier{Point: maxp, Match: maxv})
-					gocv.Rectangle(&matrix, image.Rectangle{maxp, maxp.Add(image.Pt(25, 25))}, rgba.Black.Color(), -1)
-					gocv.PutText(&matrix, fmt.Sprintf("%.1f%%", maxv*100), maxp, gocv.FontHersheyPlain, 1, rgba.White.Color(), 2)
-				case state.OrangeBaseClosed:
-					println("orange base closed: ", maxp.String(), templates[i].File)
-					orange = append(orange, Tier{Destroyed: true, Point: maxp, Match: maxv})
-					gocv.Rectangle(&matrix, image.Rectangle{maxp, maxp.Add(image.Pt(25, 25))}, rgba.Black.Color(), -1)
-					gocv.PutText(&matrix, fmt.Sprintf("%.1f%%", maxv*100), maxp, gocv.FontHersheyPlain, 1, rgba.White.Color(), 2)
-				}
+			go stats.Average(template.Truncated(), maxv)
+			go stats.Count(template.Truncated())
+
+			center := maxp.Add(marker.Div(2))
+
+			t := nearest(tiers, center, size, mirrored)
+			if t != nil && maxv > t.Match {
+				*t = Tier{Destroyed: destroyed, Point: center, Match: maxv}
+				found = true
 			}
 
-			go stats.Frequency(templates[i].Truncated(), 1)
+			// Remove the marker from the result to find the next best match.
+			gocv.Rectangle(&result, image.Rectangle{maxp.Sub(marker), maxp.Add(marker)}, rgba.Black.Color(), -1)
+		}
+
+		result.Close()
+	}
+
+	return g, found
+}
+
+// nearest returns the goal zone of a team closest to a point, or nil when no goal zone is close enough.
+func nearest(t *Tiers, p, size image.Point, mirrored bool) *Tier {
+	var tier *Tier
+
+	min := zoneDistance
+
+	for _, g := range config.Current.Goals() {
+		x := g.X
+		if mirrored {
+			x = 1 - x
+		}
+
+		dx := float64(p.X)/float64(size.X) - x
+		dy := (float64(p.Y)/float64(size.Y) - g.Y) * float64(size.Y) / float64(size.X)
+
+		d := math.Hypot(dx, dy)
+		if d < min && t.Zone(g) != nil {
+			min = d
+			tier = t.Zone(g)
 		}
 	}
 
-	return Goals{}, true
+	return tier
 }
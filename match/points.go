@@ -2,21 +2,29 @@ package match
 
 import (
 	"image"
-	"math"
 
 	"gocv.io/x/gocv"
 
 	"github.com/pidgy/unitehud/config"
 	"github.com/pidgy/unitehud/duplicate"
+	"github.com/pidgy/unitehud/filter"
 	"github.com/pidgy/unitehud/global"
-	"github.com/pidgy/unitehud/notify"
 	"github.com/pidgy/unitehud/server"
-	"github.com/pidgy/unitehud/sort"
-	"github.com/pidgy/unitehud/stats"
 	"github.com/pidgy/unitehud/team"
 	"github.com/pidgy/unitehud/template"
 )
 
+// Points reads the points of a team from an area cropped around its score, as if its score template was
+// found at the origin of the area.
+func Points(matrix gocv.Mat, t *team.Team) (Result, int) {
+	m := &Match{
+		Template: &template.Template{Filter: filter.Filter{Team: t}},
+		Max:      image.Pt(matrix.Cols(), matrix.Rows()),
+	}
+
+	return m.points(matrix)
+}
+
 func (m *Match) points(matrix gocv.Mat) (Result, int) {
 	switch m.Team.Name {
 	case team.Purple.Name, team.Orange.Name:
@@ -37,95 +45,12 @@ func (m *Match) first(matrix gocv.Mat) (Result, int) {
 		return Duplicate, -1
 	}
 
-	inset := 0
-
-	points := []int{-1, -1}
-	mins := []int{math.MaxInt32, math.MaxInt32, math.MaxInt32}
-	maxs := []float32{0, 0, 0}
-	lefts := []int{math.MaxInt32, math.MaxInt32, math.MaxInt32}
-
-	templatesWithZero := config.Current.TemplatesPoints(m.Team.Name)
-	templatesWithoutZero := []*template.Template{}
-	for _, t := range templatesWithZero {
-		if t.Value == 0 {
-			continue
-		}
-		templatesWithoutZero = append(templatesWithoutZero, t)
-	}
-
-	// Collect matched templates, exit early if we detect different images once.
-	sorted := sort.NewTemplates()
-
-	for round := 0; round < len(points); round++ {
-		templates := templatesWithoutZero
-		if round != 0 {
-			templates = templatesWithZero
-		}
-
-		region := matrix.Region(
-			image.Rectangle{
-				Min: image.Pt(inset, 0),
-				Max: image.Pt(matrix.Cols(), matrix.Rows()),
-			},
-		)
-
-		results := make([]gocv.Mat, len(templates))
-
-		for i, template := range templates {
-			if template.Mat.Cols() > region.Cols() || template.Mat.Rows() > region.Rows() {
-				return Invalid, -1
-			}
-
-			mat := gocv.NewMat()
-			defer mat.Close()
-
-			results[i] = mat
-
-			gocv.MatchTemplate(region, template.Mat, &mat, gocv.TmCcoeffNormed, template.Mask)
-		}
-
-		for i := range results {
-			if results[i].Empty() {
-				notify.SystemWarn("Empty result for %s", templates[i].Truncated())
-				continue
-			}
-
-			_, maxv, _, maxp := gocv.MinMaxLoc(results[i])
-			if math.IsInf(float64(maxv), 1) {
-				continue
-			}
-
-			go stats.Frequency(templates[i].Truncated(), maxv)
-
-			if maxv >= m.Team.Acceptance {
-				sorted.Cache(templates[i], maxp, maxv)
-
-				go stats.Average(templates[i].Truncated(), maxv)
-				go stats.Count(templates[i].Truncated())
-
-				// Select the left-most image first, when the difference is small enough,
-				// use the highest template-match value to break the tie.
-				leftmost := maxp.X < lefts[round]
-				if delta(maxp.X, lefts[round]) < 3 {
-					leftmost = maxv > maxs[round]
-				}
-
-				if leftmost {
-					lefts[round] = maxp.X
-					maxs[round] = maxv
-					mins[round] = maxp.X
-					points[round] = templates[i].Value
-				}
-			}
-		}
-
-		inset += mins[round] + 15
-		if inset > matrix.Cols() {
-			break
-		}
+	digits := Digits(matrix, config.Current.TemplatesPoints(m.Team.Name), m.Team.Acceptance)
+	if uncertain(digits, m.Team.Acceptance) {
+		return Invalid, -1
 	}
 
-	r, p := sliceToValue(points)
+	r, p := sliceToValue(values(digits, 2, false))
 	if r != Found {
 		return r, p
 	}
@@ -136,104 +61,26 @@ func (m *Match) first(matrix gocv.Mat) (Result, int) {
 func (m *Match) regular(matrix gocv.Mat) (Result, int) {
 	m.Points = []image.Point{image.Pt(0, 0), image.Pt(0, 0), image.Pt(0, 0)}
 
-	inset := 0
-
-	mins := []int{math.MaxInt32, math.MaxInt32}
-	maxs := []float32{0, 0}
-	lefts := []int{math.MaxInt32, math.MaxInt32}
-	points := []int{-1, -1}
-
+	n := 2
 	if server.IsFinalStretch() || global.DebugMode {
-		mins = []int{math.MaxInt32, math.MaxInt32, math.MaxInt32}
-		maxs = []float32{0, 0, 0}
-		lefts = []int{math.MaxInt32, math.MaxInt32, math.MaxInt32}
-		points = []int{-1, -1, -1}
+		n = 3
 	}
 
-	templates2ndRound := config.Current.TemplatesPoints(m.Team.Name)
-	templates1stRound := config.TemplatesFirstRound(templates2ndRound)
-
-	for round := 0; round < len(mins); round++ {
-		templates := templates2ndRound
-		if round == 0 {
-			templates = templates1stRound
-		}
-
-		// Cut the image in half to prevent double numbers from matching the right-most first.
-		max := image.Pt(matrix.Cols(), matrix.Rows())
-		if round == 0 {
-			max = image.Pt(matrix.Cols()/2+5, matrix.Rows())
-		}
-
-		region := matrix.Region(
-			image.Rectangle{
-				Min: image.Pt(inset, 0),
-				Max: max,
-			},
-		)
-
-		// gocv.IMWrite(fmt.Sprintf("round_%d.png", round), region)
-
-		results := make([]gocv.Mat, len(templates))
-
-		for i, template := range templates {
-			if template.Mat.Cols() > region.Cols() || template.Mat.Rows() > region.Rows() {
-				return Invalid, -1
-			}
-
-			mat := gocv.NewMat()
-			defer mat.Close()
-
-			results[i] = mat
-
-			gocv.MatchTemplate(region, template.Mat, &mat, gocv.TmCcoeffNormed, template.Mask)
-		}
-
-		for i := range results {
-			if results[i].Empty() {
-				notify.SystemWarn("Empty result for %s", templates[i].Truncated())
-				continue
-			}
-
-			_, maxv, _, maxp := gocv.MinMaxLoc(results[i])
-			if math.IsInf(float64(maxv), 1) {
-				continue
-			}
-
-			go stats.Frequency(templates[i].Truncated(), maxv)
-
-			if maxv >= m.Team.Acceptance {
-				if round > 0 && maxp.X > templates[i].Mat.Cols() {
-					maxp.X = 0
-				}
-
-				// Select the left-most image first, when the difference is small enough,
-				// use the highest template-match value to break the tie.
-				leftmost := maxp.X < lefts[round]
-				if delta(maxp.X, lefts[round]) < 5 {
-					leftmost = maxv > maxs[round]
-				}
-
-				if leftmost {
-					m.Points[round] = maxp
-					lefts[round] = maxp.X
-					maxs[round] = maxv
-					mins[round] = maxp.X + templates[i].Mat.Cols() - 1
-					points[round] = templates[i].Value
-				}
+	digits := Digits(matrix, config.Current.TemplatesPoints(m.Team.Name), m.Team.Acceptance)
+	if uncertain(digits, m.Team.Acceptance) {
+		return Invalid, -1
+	}
 
-				go stats.Average(templates[i].Truncated(), maxv)
-				go stats.Count(templates[i].Truncated())
-			}
-		}
+	// Scores never start with a zero.
+	for len(digits) > 0 && digits[0].Value == 0 {
+		digits = digits[1:]
+	}
 
-		inset += mins[round] - 5
-		if inset > matrix.Cols() {
-			break
-		}
+	for i := 0; i < n && i < len(digits); i++ {
+		m.Points[i] = digits[i].Min
 	}
 
-	r, p := sliceToValue(points)
+	r, p := sliceToValue(values(digits, n, false))
 	if r != Found {
 		return r, p
 	}
@@ -266,13 +113,6 @@ func (m *Match) validate(matrix gocv.Mat, value int) (Result, int) {
 	}
 }
 
-func delta(a, b int) int {
-	if a > b {
-		return a - b
-	}
-	return b - a
-}
-
 func sliceToValue(points []int) (Result, int) {
 	// Enforce a length 3 array to validate checks below.
 	if len(points) == 2 {
@@ -0,0 +1,60 @@
+package match
+
+import "testing"
+
+func TestSliceToValue(t *testing.T) {
+	for _, c := range []struct {
+		points []int
+		result Result
+		value  int
+	}{
+		{[]int{-1, -1}, Missed, 0},
+		{[]int{-1, -1, -1}, Missed, 0},
+		{[]int{7, -1}, Found, 7},
+		{[]int{2, 0}, Found, 20},
+		{[]int{1, 0, -1}, Found, 10},
+		{[]int{1, 0, 0}, Found, 100},
+	} {
+		r, v := sliceToValue(c.points)
+		if r != c.result || v != c.value {
+			t.Errorf("%v: expected %s %d, got %s %d", c.points, c.result, c.value, r, v)
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	digits := func(v ...int) []Digit {
+		d := []Digit{}
+		for _, n := range v {
+			d = append(d, Digit{Value: n})
+		}
+		return d
+	}
+
+	for _, c := range []struct {
+		digits []Digit
+		n      int
+		zeros  bool
+		values []int
+	}{
+		{digits(), 2, false, []int{-1, -1}},
+		{digits(2, 0), 2, false, []int{2, 0}},
+		{digits(0, 8), 2, false, []int{8, -1}},
+		{digits(0, 8), 2, true, []int{0, 8}},
+		{digits(1, 0, 0), 2, false, []int{1, 0}},
+		{digits(1, 0, 0), 3, false, []int{1, 0, 0}},
+	} {
+		v := values(c.digits, c.n, c.zeros)
+		if len(v) != len(c.values) {
+			t.Errorf("%v: expected %v, got %v", c.digits, c.values, v)
+			continue
+		}
+
+		for i := range v {
+			if v[i] != c.values[i] {
+				t.Errorf("%v: expected %v, got %v", c.digits, c.values, v)
+				break
+			}
+		}
+	}
+}
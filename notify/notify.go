@@ -3,6 +3,7 @@ package notify
 import (
 	"fmt"
 	"image"
+	"io"
 	"strings"
 	"time"
 
@@ -19,6 +20,9 @@ var (
 	Time        image.Image = image.NewRGBA(image.Rect(0, 0, 0, 0))
 )
 
+// Output receives every post appended to the feed when set, for running without a window.
+var Output io.Writer
+
 type Post struct {
 	nrgba.NRGBA
 	time.Time
@@ -193,6 +197,10 @@ func (n *notify) log(r nrgba.NRGBA, clock, dedup, unique bool, format string, a
 		}
 	}
 
+	if Output != nil {
+		fmt.Fprintln(Output, p.msg)
+	}
+
 	n.logs = append(n.logs, p)
 	if len(n.logs) > 10000 {
 		n.logs = n.logs[1:]
@@ -0,0 +1,360 @@
+// Package regression replays a corpus of labelled screenshots through the matchers used by detect, and
+// reports the accuracy of every label.
+//
+// A corpus is a directory containing corpus.json, and the PNG images it describes. Every image is
+// cropped to the selection area the matcher would normally receive.
+//
+//	{
+//		"cases": [
+//			{"file": "switch/purple-20.png", "kind": "score", "team": "purple", "value": 20},
+//			{"file": "switch/points-orange-12.png", "kind": "points", "team": "orange", "value": 12},
+//			{"file": "switch/clock-0830.png", "kind": "time", "value": 510},
+//			{"file": "switch/energy-14.png", "kind": "energy", "value": 14},
+//			{"file": "switch/ko-ally.png", "kind": "ko", "value": 32, "template": "game/ko_ally.png"},
+//			{"file": "switch/minimap-3.png", "kind": "minimap", "value": 3},
+//			{"file": "mobile/vs.png", "kind": "game", "value": 5, "template": "game/vs.png", "platform": "mobile"}
+//		]
+//	}
+//
+// Images of points cases are cropped to the points area read after a score template is found. Values of
+// ko, secure, killed, game and scoring cases are state.EventType values. Values of minimap cases are the
+// number of goal zones found, open or destroyed.
+//
+// Accuracy is reported per template file of a profile and platform. Cases read from digits count towards
+// the template of every digit of their value, and other cases towards the template they are expected to
+// match, or their kind when it is not given.
+package regression
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/img"
+	"github.com/pidgy/unitehud/match"
+	"github.com/pidgy/unitehud/team"
+	"github.com/pidgy/unitehud/template"
+)
+
+// Manifest is the name of the file describing a corpus.
+const Manifest = "corpus.json"
+
+const (
+	KindEnergy  = "energy"
+	KindGame    = "game"
+	KindKilled  = "killed"
+	KindKO      = "ko"
+	KindMiniMap = "minimap"
+	KindPoints  = "points"
+	KindScore   = "score"
+	KindScoring = "scoring"
+	KindSecure  = "secure"
+	KindTime    = "time"
+)
+
+type Case struct {
+	File     string `json:"file"`
+	Kind     string `json:"kind"`
+	Team     string `json:"team,omitempty"`
+	Value    int    `json:"value"`
+	Template string `json:"template,omitempty"` // Template file expected to match, relative to the profile assets.
+	Profile  string `json:"profile,omitempty"`  // Defaults to player.
+	Platform string `json:"platform,omitempty"` // Defaults to switch.
+}
+
+type Result struct {
+	Case
+	Got      int
+	Template string // Template matched, if any.
+	Err      error
+}
+
+type Accuracy struct {
+	Label  string
+	Passed int
+	Total  int
+}
+
+type Report struct {
+	Results  []Result
+	Accuracy []Accuracy
+	Passed   int
+}
+
+// Labels returns the template files expected to recognize the case, prefixed by its profile and platform.
+func (c Case) Labels() []string {
+	p := c.Profile
+	if p == "" {
+		p = config.ProfilePlayer
+	}
+	p = fmt.Sprintf("%s/%s/", p, c.platform())
+
+	digits := ""
+
+	switch c.Kind {
+	case KindPoints:
+		digits = fmt.Sprintf("%s/points/%d", c.Team, c.Value)
+	case KindTime:
+		digits = fmt.Sprintf("time/points/%02d%02d", c.Value/60, c.Value%60)
+	case KindEnergy:
+		digits = fmt.Sprintf("balls/points/%d", c.Value)
+	}
+
+	switch {
+	case c.Template != "":
+		return []string{p + c.Template}
+	case digits != "":
+		dir, value := path.Split(digits)
+
+		labels := []string{}
+		seen := map[rune]bool{}
+
+		for _, d := range value {
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+
+			labels = append(labels, fmt.Sprintf("%s%spoint_%c.png", p, dir, d))
+		}
+
+		return labels
+	case c.Team != "":
+		return []string{p + c.Kind + "/" + c.Team}
+	default:
+		return []string{p + c.Kind}
+	}
+}
+
+// name identifies the case by its profile, platform, kind and value.
+func (c Case) name() string {
+	p := c.Profile
+	if p == "" {
+		p = config.ProfilePlayer
+	}
+
+	s := fmt.Sprintf("%s/%s/%s", p, c.platform(), c.Kind)
+	if c.Team != "" {
+		s += "/" + c.Team
+	}
+
+	return fmt.Sprintf("%s/%d", s, c.Value)
+}
+
+func (c Case) platform() string {
+	if c.Platform == "" {
+		return config.PlatformSwitch
+	}
+	return c.Platform
+}
+
+func (r Result) Passed() bool {
+	return r.Err == nil && r.Got == r.Value
+}
+
+func (r Result) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %s (%v)", r.File, r.name(), r.Err)
+	case r.Passed():
+		return fmt.Sprintf("%s: %s ok", r.File, r.name())
+	default:
+		return fmt.Sprintf("%s: %s got %d (%s)", r.File, r.name(), r.Got, r.Template)
+	}
+}
+
+func (a Accuracy) Percent() float64 {
+	if a.Total == 0 {
+		return 0
+	}
+	return float64(a.Passed) / float64(a.Total) * 100
+}
+
+// Run replays every case described by the corpus manifest in dir. The current configuration is
+// modified to load the templates of each case profile and platform.
+func Run(dir string) (*Report, error) {
+	b, err := os.ReadFile(filepath.Join(dir, Manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := struct {
+		Cases []Case `json:"cases"`
+	}{}
+
+	err = json.Unmarshal(b, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s (%v)", Manifest, err)
+	}
+
+	cases := manifest.Cases
+
+	// Group cases by profile and platform to avoid reloading templates.
+	sort.SliceStable(cases, func(i, j int) bool {
+		return cases[i].Profile+cases[i].platform() < cases[j].Profile+cases[j].platform()
+	})
+
+	r := &Report{}
+
+	profile, platform := "", ""
+
+	for _, c := range cases {
+		if c.Profile != profile || c.platform() != platform {
+			profile, platform = c.Profile, c.platform()
+
+			p := profile
+			if p == "" {
+				p = config.ProfilePlayer
+			}
+
+			err := config.Load(p)
+			if err != nil {
+				return nil, err
+			}
+
+			config.Current.SetPlatform(platform)
+		}
+
+		res := run(filepath.Join(dir, c.File), c)
+		if res.Passed() {
+			r.Passed++
+		}
+
+		r.Results = append(r.Results, res)
+	}
+
+	r.Accuracy = accuracy(r.Results)
+
+	return r, nil
+}
+
+func accuracy(results []Result) []Accuracy {
+	labels := map[string]*Accuracy{}
+	for _, res := range results {
+		for _, l := range res.Labels() {
+			a, ok := labels[l]
+			if !ok {
+				a = &Accuracy{Label: l}
+				labels[l] = a
+			}
+
+			a.Total++
+			if res.Passed() {
+				a.Passed++
+			}
+		}
+	}
+
+	accuracy := []Accuracy{}
+	for _, a := range labels {
+		accuracy = append(accuracy, *a)
+	}
+
+	sort.Slice(accuracy, func(i, j int) bool { return accuracy[i].Label < accuracy[j].Label })
+
+	return accuracy
+}
+
+func run(file string, c Case) Result {
+	r := Result{Case: c, Got: -1}
+
+	mat := gocv.IMRead(file, gocv.IMReadColor)
+	if mat.Empty() {
+		r.Err = fmt.Errorf("failed to read image")
+		return r
+	}
+	defer mat.Close()
+
+	rgba, err := img.RGBA(mat)
+	if err != nil || rgba == nil {
+		r.Err = fmt.Errorf("failed to convert image (%v)", err)
+		return r
+	}
+
+	switch c.Kind {
+	case KindEnergy:
+		_, _, r.Got = match.Energy(mat, rgba)
+	case KindTime:
+		r.Got, _ = match.Time(mat, rgba)
+	case KindScore:
+		switch c.Team {
+		case team.Purple.Name, team.Orange.Name, team.Self.Name, team.First.Name:
+		default:
+			r.Err = fmt.Errorf("invalid team \"%s\"", c.Team)
+			return r
+		}
+
+		// Every case is a new score, not a duplicate of the previous case.
+		team.Clear()
+
+		r.match(mat, rgba, config.Current.TemplatesScored(c.Team))
+	case KindPoints:
+		var t *team.Team
+
+		switch c.Team {
+		case team.Purple.Name:
+			t = team.Purple
+		case team.Orange.Name:
+			t = team.Orange
+		case team.First.Name:
+			t = team.First
+		default:
+			r.Err = fmt.Errorf("invalid team \"%s\"", c.Team)
+			return r
+		}
+
+		team.Clear()
+
+		res, v := match.Points(mat, t)
+		if res != match.Found {
+			r.Template = res.String()
+			return r
+		}
+
+		r.Got = v
+	case KindMiniMap:
+		m, ok := match.MiniMap(mat, rgba)
+		if !ok {
+			r.Template = match.NotFound.String()
+			return r
+		}
+
+		r.Got = m.Found()
+	case KindKO:
+		r.match(mat, rgba, config.Current.TemplatesKO(team.Game.Name))
+	case KindSecure:
+		r.match(mat, rgba, config.Current.TemplatesSecure(team.Game.Name))
+	case KindKilled:
+		r.match(mat, rgba, config.Current.TemplatesKilled(team.Game.Name))
+	case KindGame:
+		r.match(mat, rgba, config.Current.TemplatesGame(team.Game.Name))
+	case KindScoring:
+		r.match(mat, rgba, config.Current.TemplatesScoring(team.Game.Name))
+	default:
+		r.Err = fmt.Errorf("unknown kind \"%s\"", c.Kind)
+	}
+
+	return r
+}
+
+func (r *Result) match(mat gocv.Mat, i image.Image, templates []*template.Template) {
+	m, res, v := match.Matches(mat, i, templates)
+	if res != match.Found || m.Template == nil {
+		r.Template = res.String()
+		return
+	}
+
+	r.Got, r.Template = v, m.Template.Truncated()
+
+	// Killed matches return the energy held, the event type is the template value.
+	if r.Kind == KindKilled {
+		r.Got = m.Template.Value
+	}
+}
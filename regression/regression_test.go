@@ -0,0 +1,66 @@
+package regression
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pidgy/unitehud/config"
+)
+
+// thresholds are the minimum accuracy percentages of template files that are known to fall short of 100%.
+var thresholds = map[string]float64{}
+
+// TestCorpus replays the seed corpus and fails when a template file falls below its threshold. Cases are
+// composed from the templates of the player profile on every platform, except for the crops of real
+// captures in testdata/corpus/captures.
+func TestCorpus(t *testing.T) {
+	corpus, err := filepath.Abs(filepath.Join("testdata", "corpus"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.AssetsDirectory, err = filepath.Abs(filepath.Join("..", "assets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { config.AssetsDirectory = "" }()
+
+	// Configuration files are saved to the working directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Run(corpus)
+	if err != nil {
+		t.Fatalf("failed to run corpus (%v)", err)
+	}
+
+	if len(r.Results) == 0 {
+		t.Fatalf("corpus has no cases")
+	}
+
+	for _, res := range r.Results {
+		if !res.Passed() {
+			t.Log(res)
+		}
+	}
+
+	for _, a := range r.Accuracy {
+		min, ok := thresholds[a.Label]
+		if !ok {
+			min = 100
+		}
+
+		if a.Percent() < min {
+			t.Errorf("%s: %.1f%% accuracy (%d/%d), expected at least %.1f%%", a.Label, a.Percent(), a.Passed, a.Total, min)
+		}
+	}
+}
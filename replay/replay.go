@@ -0,0 +1,209 @@
+// Package replay re-drives the server from a recorded event journal, so overlays and the server endpoints
+// behave as if the match were live without running detection.
+//
+// Events are applied in the order they were recorded, and the match clock is advanced one second at a
+// time between events. Vetoed events, and held events that were never accepted, are skipped.
+package replay
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/server"
+	"github.com/pidgy/unitehud/state"
+	"github.com/pidgy/unitehud/team"
+)
+
+// Run replays the journal of a match. See Play.
+func Run(file string, speed float64, stop chan bool) error {
+	events, err := state.Load(file)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return fmt.Errorf("%s has no events", file)
+	}
+
+	notify.System("Replaying %d events from %s at %.1fx", len(events), file, speed)
+
+	return Play(events, speed, stop)
+}
+
+// Play replays the events of a match, ordered from newest to oldest like state.Events(), at a multiple of
+// their recorded pace. Events are replayed as fast as possible when speed is 0. Play returns early when stop
+// is closed.
+//
+// Events after the start of another match are ignored, as journaled before matches began their own journal.
+func Play(events []*state.Event, speed float64, stop chan bool) error {
+	if speed < 0 {
+		return fmt.Errorf("invalid speed %.1f", speed)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	ordered := make([]*state.Event, len(events))
+	for i, e := range events {
+		ordered[len(events)-1-i] = e
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Time.Before(ordered[j].Time) })
+
+	for i, e := range ordered {
+		if i > 0 && e.EventType == state.MatchStarting {
+			ordered = ordered[:i]
+			break
+		}
+	}
+
+	if ordered[0].EventType != state.MatchStarting {
+		start()
+	}
+
+	for i, e := range ordered {
+		if i > 0 && !wait(ordered[i-1], e, speed, stop) {
+			notify.Warn("Replay stopped after %d/%d events", i, len(ordered))
+			return nil
+		}
+
+		if e.Vetoed {
+			continue
+		}
+
+		apply(e)
+	}
+
+	notify.System("Replayed %d events", len(ordered))
+
+	return nil
+}
+
+// apply drives the server with a recorded event, as its detector would have.
+func apply(e *state.Event) {
+	seconds, ok := clock(e.Clock)
+	if ok && e.EventType != state.MatchStarting {
+		server.SetTime(seconds/60, seconds%60)
+	}
+
+	switch e.EventType {
+	case state.MatchStarting:
+		start()
+
+		seconds = config.Current.Rules().Match
+		server.SetTime(seconds/60, seconds%60)
+	case state.MatchEnding:
+		server.SetMatchStopped()
+	case state.MatchCleared:
+		server.Clear()
+		team.Clear()
+	case state.ClockAdjusted:
+		server.SetTime(e.Value/60, e.Value%60)
+	case state.KOPurple, state.KOStreakPurple:
+		server.SetKO(team.Purple)
+	case state.KOOrange, state.KOStreakOrange:
+		server.SetKO(team.Orange)
+	case state.Killed, state.KilledWithPoints, state.KilledWithoutPoints:
+		server.SetDefeated()
+	case state.HoldingEnergy:
+		server.SetEnergy(e.Value)
+	case state.ScoreOverride:
+		// Overrides journaled before they were held for review do not record the points they replaced.
+		if e.Held && e.Credited() {
+			credit(e)
+		}
+	default:
+		if e.Credited() {
+			credit(e)
+			break
+		}
+
+		o, t, ok := config.Current.Rules().Secured(e.EventType)
+		if ok {
+			server.SetSecured(t, o.Name)
+		}
+	}
+
+	state.Repeat(e, server.Clock())
+}
+
+// start clears the server and event log for a new match.
+func start() {
+	server.Clear()
+	team.Clear()
+	state.Clear()
+
+	server.SetMatchStarted()
+}
+
+// credit adds the points of a score event to its team, as the review of the event did. The first goal is
+// credited to purple when its side was not journaled, as in journals recorded before the side was resolved
+// at detection.
+func credit(e *state.Event) {
+	c := *e
+
+	switch c.Payload.Team {
+	case team.Orange.Name, team.Self.Name:
+	default:
+		c.Payload.Team = team.Purple.Name
+	}
+
+	server.Credit(&c)
+}
+
+// wait sleeps for the time between two events, divided by speed, setting the match clock every recorded
+// second. Returns false when stop is closed.
+func wait(from, to *state.Event, speed float64, stop chan bool) bool {
+	if speed == 0 {
+		select {
+		case <-stop:
+			return false
+		default:
+			return true
+		}
+	}
+
+	elapsed := to.Time.Sub(from.Time)
+	if elapsed <= 0 {
+		return true
+	}
+
+	seconds, ticking := server.Seconds(), server.Match()
+
+	for tick := time.Second; ; tick += time.Second {
+		step := time.Second
+		if tick > elapsed {
+			step = elapsed - (tick - time.Second)
+		}
+
+		select {
+		case <-stop:
+			return false
+		case <-time.After(time.Duration(float64(step) / speed)):
+		}
+
+		if tick >= elapsed {
+			return true
+		}
+
+		if ticking && seconds-int(tick/time.Second) > 0 {
+			s := seconds - int(tick/time.Second)
+			server.SetTime(s/60, s%60)
+		}
+	}
+}
+
+// clock returns the seconds remaining of a recorded match clock.
+func clock(c string) (int, bool) {
+	minutes, seconds := 0, 0
+
+	_, err := fmt.Sscanf(c, "%d:%d", &minutes, &seconds)
+	if err != nil || minutes+seconds == 0 {
+		return 0, false
+	}
+
+	return minutes*60 + seconds, true
+}
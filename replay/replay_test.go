@@ -0,0 +1,72 @@
+package replay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pidgy/unitehud/server"
+	"github.com/pidgy/unitehud/state"
+)
+
+// TestRun replays a journal as fast as possible, and expects the server to send the scores of the match.
+// The journal has a correction of the self score, an accepted and an unreviewed held score, a vetoed score
+// and a first goal journaled without its side.
+func TestRun(t *testing.T) {
+	state.Journal = ""
+
+	server.Address = "127.0.0.1:0"
+
+	err := server.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Run("testdata/match.events", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/http", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("/http replied %d", w.Code)
+	}
+
+	type score struct {
+		Value int `json:"value"`
+		KOs   int `json:"kos"`
+	}
+
+	g := struct {
+		Match   bool  `json:"match"`
+		Seconds int   `json:"seconds"`
+		Purple  score `json:"purple"`
+		Orange  score `json:"orange"`
+		Self    score `json:"self"`
+		Stacks  int   `json:"stacks"`
+	}{}
+
+	err = json.Unmarshal(w.Body.Bytes(), &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !g.Match || g.Seconds != 450 {
+		t.Fatalf("match %t at %d seconds, expected true at 450 seconds", g.Match, g.Seconds)
+	}
+
+	if g.Purple.Value != 60 || g.Orange.Value != 30 || g.Self.Value != 20 {
+		t.Fatalf("scores %d/%d (self %d), expected 60/30 (self 20)", g.Purple.Value, g.Orange.Value, g.Self.Value)
+	}
+
+	if g.Purple.KOs != 1 || g.Orange.KOs != 0 {
+		t.Fatalf("KOs %d/%d, expected 1/0", g.Purple.KOs, g.Orange.KOs)
+	}
+
+	if g.Stacks != 1 {
+		t.Fatalf("%d stacks, expected 1", g.Stacks)
+	}
+}
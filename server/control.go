@@ -0,0 +1,317 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/state"
+	"github.com/pidgy/unitehud/team"
+)
+
+// Action is a request made through the control endpoints that must be handled outside of the server.
+type Action string
+
+const (
+	Start = Action("start")
+	Stop  = Action("stop")
+)
+
+// Actions receives every start and stop request made through /control/match.
+var Actions = make(chan Action, 16)
+
+type controlClock struct {
+	Minutes int `json:"minutes"`
+	Seconds int `json:"seconds"`
+}
+
+type controlMatch struct {
+	Action string `json:"action"`
+}
+
+type controlObjective struct {
+	Name  string `json:"name"`
+	Team  string `json:"team"`
+	Slot  int    `json:"slot"`
+	Clear bool   `json:"clear"`
+}
+
+type controlScore struct {
+	Team  string `json:"team"`
+	Value int    `json:"value"`
+}
+
+func control() {
+	http.HandleFunc("/control/clock", authorized(func(r *http.Request) error {
+		c := controlClock{}
+
+		err := json.NewDecoder(r.Body).Decode(&c)
+		if err != nil {
+			return err
+		}
+
+		if c.Minutes < 0 || c.Seconds < 0 || c.Seconds > 59 || c.Minutes*60+c.Seconds > config.Current.Rules().Match {
+			return fmt.Errorf("invalid clock %02d:%02d", c.Minutes, c.Seconds)
+		}
+
+		SetTime(c.Minutes, c.Seconds)
+
+		state.Add(state.ClockAdjusted, Clock(), c.Minutes*60+c.Seconds)
+
+		notify.Unique(team.Game.NRGBA, "[Control] Clock set to %s", Clock())
+
+		return nil
+	}))
+
+	http.HandleFunc("/control/match", authorized(func(r *http.Request) error {
+		m := controlMatch{}
+
+		err := json.NewDecoder(r.Body).Decode(&m)
+		if err != nil {
+			return err
+		}
+
+		switch m.Action {
+		case string(Start), string(Stop):
+			select {
+			case Actions <- Action(m.Action):
+			default:
+				return fmt.Errorf("too many pending actions")
+			}
+		case "clear":
+			Clear()
+			team.Clear()
+
+			state.Add(state.MatchCleared, Clock(), -1)
+
+			notify.Unique(team.Game.NRGBA, "[Control] Match cleared")
+		default:
+			return fmt.Errorf("unknown action \"%s\"", m.Action)
+		}
+
+		return nil
+	}))
+
+	http.HandleFunc("/control/objective", authorized(func(r *http.Request) error {
+		o := controlObjective{}
+
+		err := json.NewDecoder(r.Body).Decode(&o)
+		if err != nil {
+			return err
+		}
+
+		if o.Clear {
+			return clearObjective(o.Name, o.Slot)
+		}
+
+		t, err := teamOf(o.Team, team.Purple, team.Orange)
+		if err != nil {
+			return err
+		}
+
+		return setObjective(o.Name, t, o.Slot)
+	}))
+
+	http.HandleFunc("/control/score", authorized(func(r *http.Request) error {
+		s := controlScore{}
+
+		err := json.NewDecoder(r.Body).Decode(&s)
+		if err != nil {
+			return err
+		}
+
+		t, err := teamOf(s.Team, team.Purple, team.Orange, team.Self)
+		if err != nil {
+			return err
+		}
+
+		err = adjust(t.Name, s.Value)
+		if err != nil {
+			return err
+		}
+
+		state.Add(state.ScoreAdjustedBy(t.Name), Clock(), s.Value)
+
+		notify.Unique(t.NRGBA, "[Control] [%s] %+d", strings.Title(t.Name), s.Value)
+
+		return nil
+	}))
+}
+
+// authorized wraps a control handler, rejecting requests that are not POST requests carrying the
+// configured server token. Successful requests are sent the versioned server response.
+func authorized(h func(r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			fail(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires a POST request", r.URL.Path))
+			return
+		}
+
+		want := "Bearer " + config.Current.ServerToken
+		got := r.Header.Get("Authorization")
+		if config.Current.ServerToken == "" || subtle.ConstantTimeCompare([]byte(want), []byte(got)) != 1 {
+			notify.Warn("Server rejected an unauthorized %s request from %s", r.URL.Path, r.RemoteAddr)
+			fail(w, http.StatusUnauthorized, fmt.Errorf("invalid server token"))
+			return
+		}
+
+		err := h(r)
+		if err != nil {
+			notify.Warn("[Control] %s failed (%v)", r.URL.Path, err)
+			fail(w, http.StatusBadRequest, err)
+			return
+		}
+
+		raw, err := marshalV2()
+		if err != nil {
+			notify.Error("Server failed to create server response (%v)", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		_, err = w.Write(raw)
+		if err != nil {
+			notify.Error("Server failed to send server response (%v)", err)
+			return
+		}
+
+		current.client(r, r.URL.Path, raw)
+	}
+}
+
+func clearObjective(name string, n int) error {
+	g := current.snapshot()
+
+	o, ok := config.Current.Rules().Objective(name)
+	if !ok {
+		return fmt.Errorf("unknown objective \"%s\"", name)
+	}
+
+	switch o.Lane {
+	case config.LaneTop:
+		if n < 0 || n >= len(g.Regilekis) {
+			return fmt.Errorf("invalid %s slot %d", name, n)
+		}
+
+		t := g.Regilekis[n]
+		if t == team.None.Name {
+			return nil
+		}
+
+		if n+1 < len(g.Regilekis) && g.Regilekis[n+1] != team.None.Name {
+			return fmt.Errorf("%s #%d must be cleared first", name, n+2)
+		}
+
+		owner, err := teamOf(t, team.Purple, team.Orange)
+		if err != nil {
+			return err
+		}
+
+		SetRegielekiAt(owner, n)
+	case config.LaneBottom:
+		if n < 0 || n >= len(g.Bottom) {
+			return nil
+		}
+
+		if n != len(g.Bottom)-1 {
+			return fmt.Errorf("%s #%d must be cleared first", g.Bottom[n+1].Name, n+2)
+		}
+
+		b := g.Bottom[n]
+		if b.Name != name {
+			return fmt.Errorf("slot %d is %s, not %s", n, b.Name, name)
+		}
+
+		owner, err := teamOf(b.Team, team.Purple, team.Orange)
+		if err != nil {
+			return err
+		}
+
+		SetBottomObjective(owner, b.Name, n)
+	case config.LaneMiddle:
+		ClearRayquaza()
+	}
+
+	state.Add(state.ObjectiveCleared, Clock(), n)
+
+	return nil
+}
+
+func setObjective(name string, t *team.Team, n int) error {
+	g := current.snapshot()
+
+	o, ok := config.Current.Rules().Objective(name)
+	if !ok {
+		return fmt.Errorf("unknown objective \"%s\"", name)
+	}
+
+	switch o.Lane {
+	case config.LaneTop:
+		if n < 0 || n >= len(g.Regilekis) {
+			return fmt.Errorf("invalid %s slot %d", name, n)
+		}
+
+		if n != 0 && g.Regilekis[n-1] == team.None.Name {
+			return fmt.Errorf("%s #%d must be secured first", name, n)
+		}
+
+		if g.Regilekis[n] == t.Name {
+			return nil
+		}
+
+		SetRegielekiAt(t, n)
+	case config.LaneBottom:
+		if n < 0 || n > len(g.Bottom) {
+			return fmt.Errorf("invalid %s slot %d", name, n)
+		}
+
+		if n < len(g.Bottom) && g.Bottom[n].Name == name && g.Bottom[n].Team == t.Name {
+			return nil
+		}
+
+		SetBottomObjective(t, name, n)
+	case config.LaneMiddle:
+		if g.Rayquaza == t.Name {
+			return nil
+		}
+
+		SetRayquaza(t)
+	}
+
+	state.Add(o.Secured(t), Clock(), n)
+
+	return nil
+}
+
+func fail(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	raw, _ := json.Marshal(map[string]string{"error": err.Error()})
+
+	_, err = w.Write(raw)
+	if err != nil {
+		notify.Error("Server failed to send server response (%v)", err)
+	}
+}
+
+func teamOf(name string, teams ...*team.Team) (*team.Team, error) {
+	for _, t := range teams {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	names := []string{}
+	for _, t := range teams {
+		names = append(names, t.Name)
+	}
+
+	return nil, fmt.Errorf("invalid team \"%s\", expected %s", name, strings.Join(names, ", "))
+}
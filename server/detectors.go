@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/team"
+)
+
+// Detector is a detection routine registered in the detect package, which imports the server. Detectors
+// are encoded as JSON by /detectors.
+type Detector interface {
+	Name() string
+	Enabled() bool
+	Enable(enabled bool)
+}
+
+// Detectors returns every registered detector, and is set by the detect package.
+var Detectors = func() []Detector { return nil }
+
+type controlDetector struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// detectors registers the detector endpoints.
+//
+//	/detectors
+//	/control/detector {"name": "energy", "enabled": false}
+func detectors() {
+	http.HandleFunc("/detectors", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, r, Detectors())
+	})
+
+	http.HandleFunc("/control/detector", authorized(func(r *http.Request) error {
+		c := controlDetector{}
+
+		err := json.NewDecoder(r.Body).Decode(&c)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range Detectors() {
+			if !strings.EqualFold(d.Name(), c.Name) {
+				continue
+			}
+
+			d.Enable(c.Enabled)
+
+			status := "disabled"
+			if c.Enabled {
+				status = "enabled"
+			}
+
+			notify.Unique(team.Game.NRGBA, "[Control] %s detection %s", d.Name(), status)
+
+			return nil
+		}
+
+		return fmt.Errorf("unknown detector \"%s\"", c.Name)
+	}))
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/team"
+)
+
+// Goal is a goal zone of a team. Goal zones that have not been found on the minimap have no match, and
+// goal zones that cannot be destroyed have no capacity.
+type Goal struct {
+	Tier      int     `json:"tier"`
+	Lane      string  `json:"lane"`
+	Destroyed bool    `json:"destroyed"`
+	Match     float32 `json:"match"`
+	Capacity  int     `json:"capacity"`
+	Remaining int     `json:"remaining"`
+}
+
+type goals struct {
+	Purple []Goal `json:"purple"`
+	Orange []Goal `json:"orange"`
+}
+
+// SetGoals updates the goal zones of a team found on the minimap. Goal zones that were not found keep their
+// previous state, and destroyed goal zones are never restored.
+func SetGoals(t *team.Team, found []Goal) {
+	current.write(func(g *game) {
+		goals := g.Goals.Purple
+		if t.Name == team.Orange.Name {
+			goals = g.Goals.Orange
+		}
+
+		for i := range goals {
+			for _, f := range found {
+				if f.Tier != goals[i].Tier || f.Lane != goals[i].Lane || f.Match == 0 {
+					continue
+				}
+
+				goals[i].Match = f.Match
+				goals[i].Destroyed = goals[i].Destroyed || f.Destroyed
+			}
+		}
+
+		g.estimate()
+	})
+}
+
+// estimate updates the points remaining in every goal zone from the points scored by the opposing team.
+func (g *game) estimate() {
+	remaining(g.Goals.Purple, g.Orange.Value)
+	remaining(g.Goals.Orange, g.Purple.Value)
+}
+
+// destroyed returns true if the goal zone of a tier and lane has been destroyed.
+func destroyed(goals []Goal, tier int, lane string) bool {
+	for _, g := range goals {
+		if g.Tier == tier && g.Lane == lane {
+			return g.Destroyed
+		}
+	}
+	return false
+}
+
+func newGoals() []Goal {
+	goals := []Goal{}
+
+	for _, g := range config.Current.Goals() {
+		goals = append(goals, Goal{
+			Tier:      g.Tier,
+			Lane:      g.Lane,
+			Capacity:  g.Capacity,
+			Remaining: g.Capacity,
+		})
+	}
+
+	return goals
+}
+
+// remaining estimates the points remaining in each goal zone. Scored points are attributed to destroyed goal
+// zones up to their capacity, the rest are split evenly between the goal zones that can be scored in, which
+// are the first tier and any tier behind a destroyed goal zone of the same lane.
+func remaining(goals []Goal, scored int) {
+	exposed := []int{}
+
+	for i := range goals {
+		goals[i].Remaining = goals[i].Capacity
+
+		switch {
+		case goals[i].Destroyed:
+			goals[i].Remaining = 0
+			scored -= goals[i].Capacity
+		case goals[i].Capacity == 0:
+		case goals[i].Tier == 1, destroyed(goals, goals[i].Tier-1, goals[i].Lane):
+			exposed = append(exposed, i)
+		}
+	}
+
+	if scored <= 0 || len(exposed) == 0 {
+		return
+	}
+
+	for _, i := range exposed {
+		goals[i].Remaining -= scored / len(exposed)
+		if goals[i].Remaining < 0 {
+			goals[i].Remaining = 0
+		}
+	}
+}
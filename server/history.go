@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pidgy/unitehud/history"
+	"github.com/pidgy/unitehud/notify"
+)
+
+// matches registers the match history endpoints.
+//
+//	/history?since=2023-01-01&until=2023-01-31&result=win
+//	/history/{id}
+func matches() {
+	http.HandleFunc("/history", func(w http.ResponseWriter, r *http.Request) {
+		f, err := filter(r)
+		if err != nil {
+			fail(w, http.StatusBadRequest, err)
+			return
+		}
+
+		m := history.Matches(f)
+		for i := range m {
+			m[i].Events = nil
+		}
+
+		reply(w, r, m)
+	})
+
+	http.HandleFunc("/history/", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/history/"), 10, 64)
+		if err != nil {
+			fail(w, http.StatusBadRequest, fmt.Errorf("invalid match id"))
+			return
+		}
+
+		m, ok := history.Get(id)
+		if !ok {
+			fail(w, http.StatusNotFound, fmt.Errorf("match %d does not exist", id))
+			return
+		}
+
+		reply(w, r, m)
+	})
+}
+
+// filter parses the since, until and result query parameters. Dates are accepted as RFC 3339 times or
+// as YYYY-MM-DD, where an until date includes the entire day.
+func filter(r *http.Request) (history.Filter, error) {
+	f := history.Filter{}
+
+	q := r.URL.Query()
+
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+		day  time.Duration
+	}{
+		{"since", &f.Since, 0},
+		{"until", &f.Until, time.Hour*24 - time.Nanosecond},
+	} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, v)
+		if err == nil {
+			*p.t = t
+			continue
+		}
+
+		t, err = time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("invalid %s date \"%s\"", p.name, v)
+		}
+
+		*p.t = t.Add(p.day)
+	}
+
+	f.Result = q.Get("result")
+	switch f.Result {
+	case "", history.Win, history.Loss, history.Tie:
+	default:
+		return f, fmt.Errorf("invalid result \"%s\", expected %s, %s, %s", f.Result, history.Win, history.Loss, history.Tie)
+	}
+
+	return f, nil
+}
+
+func reply(w http.ResponseWriter, r *http.Request, v interface{}) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		notify.Error("Server failed to create server response (%v)", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_, err = w.Write(raw)
+	if err != nil {
+		notify.Error("Server failed to send server response (%v)", err)
+		return
+	}
+
+	current.client(r, r.URL.Path, raw)
+}
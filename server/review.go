@@ -0,0 +1,214 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/state"
+	"github.com/pidgy/unitehud/team"
+)
+
+type controlReview struct {
+	ID     int    `json:"id"`
+	Action string `json:"action"`
+	Points *int   `json:"points"` // Optional correction of the points credited when accepted.
+}
+
+type held struct {
+	Event *state.Event `json:"event"`
+	Image string       `json:"image"` // PNG data URI of the capture the event was detected in.
+}
+
+// reviews registers the score review endpoints.
+//
+//	/review
+//	/control/review {"id": 12, "action": "accept", "points": 20}
+func reviews() {
+	http.HandleFunc("/review", func(w http.ResponseWriter, r *http.Request) {
+		h := []held{}
+
+		for _, e := range state.Reviews() {
+			uri := ""
+
+			if e.Image != nil {
+				buf := &bytes.Buffer{}
+
+				err := png.Encode(buf, e.Image)
+				if err != nil {
+					notify.Warn("Server failed to encode capture of event #%d (%v)", e.ID(), err)
+				} else {
+					uri = "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+				}
+			}
+
+			h = append(h, held{Event: e.Event, Image: uri})
+		}
+
+		reply(w, r, h)
+	})
+
+	http.HandleFunc("/control/review", authorized(func(r *http.Request) error {
+		c := controlReview{}
+
+		err := json.NewDecoder(r.Body).Decode(&c)
+		if err != nil {
+			return err
+		}
+
+		switch c.Action {
+		case "accept":
+			return Accept(c.ID, c.Points)
+		case "veto":
+			return Veto(c.ID)
+		default:
+			return fmt.Errorf("unknown action \"%s\"", c.Action)
+		}
+	}))
+}
+
+// Accept verifies an event of the current match, and credits the points it adds to the score of its team.
+// The points credited by the event are corrected first when points is not nil.
+func Accept(id int, points *int) error {
+	err := review(id, func(e *state.Event) error {
+		if e.Vetoed {
+			return fmt.Errorf("event #%d was vetoed", id)
+		}
+
+		if points != nil {
+			e.Correct(*points)
+		}
+
+		e.Verify()
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e := state.Find(id)
+
+	notify.Unique(team.Game.NRGBA, "[Review] [%s] %s accepted (%d)", e.Clock, e.EventType, e.Payload.Points)
+
+	return nil
+}
+
+// Veto vetoes an event of the current match, and removes any points it credited from the score of its team.
+func Veto(id int) error {
+	err := review(id, func(e *state.Event) error {
+		e.Veto()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e := state.Find(id)
+
+	notify.Unique(team.Game.NRGBA, "[Review] [%s] %s vetoed", e.Clock, e.EventType)
+
+	return nil
+}
+
+// review changes an event of the current match, and credits the difference the change makes to the team
+// totals of the event log. The change and the totals are made inside one write of the game, and only count
+// the events logged before the change, so concurrent reviews and detections are credited once.
+func review(id int, change func(e *state.Event) error) error {
+	var err error
+
+	current.write(func(g *game) {
+		e := state.Find(id)
+		if e == nil {
+			err = fmt.Errorf("event #%d does not exist", id)
+			return
+		}
+
+		events := state.Events()
+		last := events[0].ID()
+
+		before := totals(events, last)
+
+		err = change(e)
+		if err != nil {
+			return
+		}
+
+		after := totals(state.Events(), last)
+
+		g.credit(tally{
+			purple: after.purple - before.purple,
+			orange: after.orange - before.orange,
+			self:   after.self - before.self,
+			stacks: after.stacks - before.stacks,
+		})
+	})
+
+	return err
+}
+
+// Credit adds the points credited by an event to the score of its team, counting them the way a review
+// accepting the event would.
+func Credit(e *state.Event) {
+	t := tally{}
+	t.count(e)
+
+	current.write(func(g *game) { g.credit(t) })
+}
+
+type tally struct {
+	purple, orange, self, stacks int
+}
+
+// totals returns the points credited to each team by the events up to and including an ID.
+func totals(events []*state.Event, last int) tally {
+	t := tally{}
+
+	for _, e := range events {
+		if e.ID() <= last {
+			t.count(e)
+		}
+	}
+
+	return t
+}
+
+// count adds the points credited by an event to the total of its team. Self scores also count towards
+// the purple score, and those that are not corrections count as stacks.
+func (t *tally) count(e *state.Event) {
+	if !e.Credited() {
+		return
+	}
+
+	name := e.Payload.Team
+	if name == team.First.Name {
+		name = team.First.Alias
+	}
+
+	switch name {
+	case team.Purple.Name:
+		t.purple += e.Payload.Points
+	case team.Orange.Name:
+		t.orange += e.Payload.Points
+	case team.Self.Name:
+		t.purple += e.Payload.Points
+		t.self += e.Payload.Points
+
+		if e.EventType != state.ScoreAdjustedSelf {
+			t.stacks++
+		}
+	}
+}
+
+func (g *game) credit(t tally) {
+	g.Purple.Value += t.purple
+	g.Orange.Value += t.orange
+	g.Self.Value += t.self
+	g.Stacks += t.stacks
+
+	g.estimate()
+}
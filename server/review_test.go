@@ -0,0 +1,64 @@
+package server
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pidgy/unitehud/state"
+)
+
+// TestReview accepts held scores from several reviewers at once, and expects each score to be credited once.
+func TestReview(t *testing.T) {
+	Clear()
+	defer Clear()
+
+	state.Clear()
+	defer state.Clear()
+
+	purple := state.Hold(state.PurpleScore, "09:00", 20, state.Payload{}, nil)
+	self := state.Hold(state.PostScore, "08:30", 15, state.Payload{}, nil)
+	orange := state.Hold(state.OrangeScore, "08:00", 30, state.Payload{}, nil)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			Accept(purple.ID(), nil)
+			Accept(self.ID(), nil)
+		}()
+	}
+	wg.Wait()
+
+	err := Veto(orange.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Accept(orange.ID(), nil)
+	if err == nil {
+		t.Fatalf("accepted vetoed event #%d", orange.ID())
+	}
+
+	current.read(func(g *game) {
+		if g.Purple.Value != 35 || g.Orange.Value != 0 || g.Self.Value != 15 {
+			t.Fatalf("scores %d/%d (self %d), expected 35/0 (self 15)", g.Purple.Value, g.Orange.Value, g.Self.Value)
+		}
+
+		if g.Stacks != 1 {
+			t.Fatalf("%d stacks, expected 1", g.Stacks)
+		}
+	})
+
+	err = Veto(self.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current.read(func(g *game) {
+		if g.Purple.Value != 20 || g.Self.Value != 0 || g.Stacks != 0 {
+			t.Fatalf("scores %d (self %d, %d stacks), expected 20 (self 0, 0 stacks)", g.Purple.Value, g.Self.Value, g.Stacks)
+		}
+	})
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/global"
+	"github.com/pidgy/unitehud/state"
+	"github.com/pidgy/unitehud/team"
+)
+
+// Schema is the version of the payload served by /v2/state. Fields may be added to a schema version,
+// but they are never renamed or removed without incrementing the version.
+const Schema = 2
+
+const (
+	PhaseStopped      = "stopped"
+	PhaseWaiting      = "waiting"
+	PhaseMatch        = "match"
+	PhaseFinalStretch = "final-stretch"
+)
+
+type stateV2 struct {
+	Schema     int           `json:"schema"`
+	Version    string        `json:"version"`
+	Profile    string        `json:"profile"`
+	Phase      string        `json:"phase"`
+	Clock      clockV2       `json:"clock"`
+	Purple     teamV2        `json:"purple"`
+	Orange     teamV2        `json:"orange"`
+	Self       selfV2        `json:"self"`
+	Objectives []objectiveV2 `json:"objectives"`
+	Goals      goals         `json:"goals"`
+	Spawns     []Spawn       `json:"spawns"`
+	Events     []string      `json:"events"`
+}
+
+type clockV2 struct {
+	Seconds int    `json:"seconds"`
+	Display string `json:"display"`
+}
+
+type teamV2 struct {
+	Score int `json:"score"`
+	KOs   int `json:"kos"`
+}
+
+type selfV2 struct {
+	Score    int   `json:"score"`
+	Energy   int   `json:"energy"`
+	Stacks   int   `json:"stacks"`
+	Defeated []int `json:"defeated"`
+}
+
+type objectiveV2 struct {
+	Name string `json:"name"`
+	Team string `json:"team"`
+	Slot int    `json:"slot"`
+	Time int64  `json:"time"`
+}
+
+// schemaV2 is the JSON Schema document describing stateV2, served by /schema.
+const schemaV2 = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"$id": "http://127.0.0.1:17069/schema",
+	"title": "UniteHUD match state",
+	"description": "Match state served by /v2/state.",
+	"type": "object",
+	"required": ["schema", "version", "profile", "phase", "clock", "purple", "orange", "self", "objectives", "events"],
+	"properties": {
+		"schema": {
+			"description": "Version of this schema, incremented when a field is renamed or removed.",
+			"const": 2
+		},
+		"version": {
+			"description": "UniteHUD application version.",
+			"type": "string"
+		},
+		"profile": {
+			"description": "Active UniteHUD profile.",
+			"enum": ["player", "broadcaster"]
+		},
+		"phase": {
+			"description": "Current match phase.",
+			"enum": ["stopped", "waiting", "match", "final-stretch"]
+		},
+		"clock": {
+			"type": "object",
+			"required": ["seconds", "display"],
+			"properties": {
+				"seconds": {
+					"description": "Seconds remaining in the match.",
+					"type": "integer",
+					"minimum": 0
+				},
+				"display": {
+					"description": "Seconds remaining in the match formatted as MM:SS.",
+					"type": "string"
+				}
+			}
+		},
+		"purple": {"$ref": "#/$defs/team"},
+		"orange": {"$ref": "#/$defs/team"},
+		"self": {
+			"type": "object",
+			"required": ["score", "energy", "stacks", "defeated"],
+			"properties": {
+				"score": {
+					"description": "Points scored by the player.",
+					"type": "integer"
+				},
+				"energy": {
+					"description": "Aeos energy held by the player.",
+					"type": "integer"
+				},
+				"stacks": {
+					"description": "Number of goals scored by the player.",
+					"type": "integer"
+				},
+				"defeated": {
+					"description": "Match clock, in seconds remaining, of every time the player was defeated.",
+					"type": "array",
+					"items": {"type": "integer"}
+				}
+			}
+		},
+		"objectives": {
+			"description": "Objectives secured during the match, top lane objectives first, followed by bottom lane objectives and the middle objective. Names are defined by the rules of the current map.",
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["name", "team", "slot", "time"],
+				"properties": {
+					"name": {
+						"description": "Objective name, regieleki, regice, regirock, registeel or rayquaza on Theia Sky Ruins.",
+						"type": "string"
+					},
+					"team": {
+						"enum": ["purple", "orange"]
+					},
+					"slot": {
+						"description": "Index of the objective spawn, starting at 0.",
+						"type": "integer",
+						"minimum": 0
+					},
+					"time": {
+						"description": "Unix time the objective was secured, 0 when unknown.",
+						"type": "integer"
+					}
+				}
+			}
+		},
+		"goals": {
+			"description": "Goal zones of each team, in the order tier 1 top, tier 1 bottom, tier 2 top, tier 2 bottom and tier 3 middle.",
+			"type": "object",
+			"properties": {
+				"purple": {"$ref": "#/$defs/goals"},
+				"orange": {"$ref": "#/$defs/goals"}
+			}
+		},
+		"spawns": {
+			"description": "Next spawn of every objective that can still spawn, estimated from the match clock and the last secure of each objective.",
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["name", "seconds", "clock"],
+				"properties": {
+					"name": {
+						"description": "Spawn name, regieleki, bottom or rayquaza on Theia Sky Ruins.",
+						"type": "string"
+					},
+					"seconds": {
+						"description": "Seconds until the objective spawns, 0 when it has spawned.",
+						"type": "integer",
+						"minimum": 0
+					},
+					"clock": {
+						"description": "Match clock of the spawn formatted as MM:SS.",
+						"type": "string"
+					}
+				}
+			}
+		},
+		"events": {
+			"description": "Match events from the last 5 seconds.",
+			"type": "array",
+			"items": {"type": "string"}
+		}
+	},
+	"$defs": {
+		"goals": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["tier", "lane", "destroyed", "match", "capacity", "remaining"],
+				"properties": {
+					"tier": {
+						"enum": [1, 2, 3]
+					},
+					"lane": {
+						"enum": ["top", "bottom", "middle"]
+					},
+					"destroyed": {
+						"description": "Whether the goal zone has been destroyed.",
+						"type": "boolean"
+					},
+					"match": {
+						"description": "Match confidence of the goal zone marker, 0 when the goal zone has not been found.",
+						"type": "number"
+					},
+					"capacity": {
+						"description": "Points the goal zone holds before it is destroyed, 0 when the goal zone cannot be destroyed.",
+						"type": "integer"
+					},
+					"remaining": {
+						"description": "Estimated points remaining before the goal zone is destroyed, from the points scored by the opposing team.",
+						"type": "integer"
+					}
+				}
+			}
+		},
+		"team": {
+			"type": "object",
+			"required": ["score", "kos"],
+			"properties": {
+				"score": {
+					"description": "Points scored by the team.",
+					"type": "integer"
+				},
+				"kos": {
+					"description": "Opposing Pokémon knocked out by the team.",
+					"type": "integer"
+				}
+			}
+		}
+	}
+}`
+
+func marshalV2() ([]byte, error) {
+	return json.Marshal(current.snapshot().v2())
+}
+
+func (g *game) phase() string {
+	switch {
+	case !g.Started:
+		return PhaseStopped
+	case !g.Match:
+		return PhaseWaiting
+	case g.finalStretch():
+		return PhaseFinalStretch
+	default:
+		return PhaseMatch
+	}
+}
+
+func (g *game) v2() *stateV2 {
+	s := &stateV2{
+		Schema:  Schema,
+		Version: global.Version,
+		Profile: config.Current.Profile,
+		Phase:   g.phase(),
+		Clock: clockV2{
+			Seconds: g.Seconds,
+			Display: g.clock(),
+		},
+		Purple: teamV2{
+			Score: g.Purple.Value,
+			KOs:   g.Purple.KOs,
+		},
+		Orange: teamV2{
+			Score: g.Orange.Value,
+			KOs:   g.Orange.KOs,
+		},
+		Self: selfV2{
+			Score:    g.Self.Value,
+			Energy:   g.Energy,
+			Stacks:   g.Stacks,
+			Defeated: append([]int{}, g.Defeated...),
+		},
+		Objectives: []objectiveV2{},
+		Goals:      g.Goals,
+		Spawns:     g.spawns(),
+		Events:     state.Strings(time.Second * 5),
+	}
+
+	for n, t := range g.Regilekis {
+		if t == team.None.Name {
+			continue
+		}
+
+		s.Objectives = append(s.Objectives, objectiveV2{
+			Name: config.Current.Rules().Top().Name,
+			Team: t,
+			Slot: n,
+			Time: g.regielekiTimes[n],
+		})
+	}
+
+	for n, o := range g.Bottom {
+		s.Objectives = append(s.Objectives, objectiveV2{
+			Name: o.Name,
+			Team: o.Team,
+			Slot: n,
+			Time: o.Time,
+		})
+	}
+
+	if g.Rayquaza != "" {
+		s.Objectives = append(s.Objectives, objectiveV2{
+			Name: config.Current.Rules().Middle().Name,
+			Team: g.Rayquaza,
+			Time: g.rayquazaTime,
+		})
+	}
+
+	return s
+}
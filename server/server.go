@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -20,7 +21,18 @@ import (
 	"github.com/pidgy/unitehud/team"
 )
 
-const Address = "127.0.0.1:17069"
+// Address is the address the server listens on, and must be set before calling Listen.
+var Address = "127.0.0.1:17069"
+
+const (
+	// heartbeat is the maximum duration between messages sent to a streaming client.
+	heartbeat = time.Second * 5
+	// idle is the duration after which a client that has not been seen is disconnected, long enough for
+	// streaming clients to miss a few heartbeats.
+	idle = heartbeat * 3
+	// deadline is the maximum duration a streaming client has to accept a message.
+	deadline = time.Second * 2
+)
 
 type game struct {
 	Bottom    []objective `json:"bottom"`
@@ -28,6 +40,7 @@ type game struct {
 	Defeated  []int       `json:"defeated"`
 	Energy    int         `json:"balls"`
 	Events    []string    `json:"events"`
+	Goals     goals       `json:"goals"`
 	Match     bool        `json:"match"`
 	Orange    *score      `json:"orange"`
 	Purple    *score      `json:"purple"`
@@ -35,16 +48,34 @@ type game struct {
 	Rayquaza  string      `json:"rayquaza"`
 	Regilekis []string    `json:"regis"`
 	Seconds   int         `json:"seconds"`
+	Spawns    []Spawn     `json:"spawns"`
 	Self      *score      `json:"self"`
 	Stacks    int         `json:"stacks"`
 	Started   bool        `json:"started"`
 	Version   string      `json:"version"`
 
 	lastSecondsUpdate time.Time
+	matchStarted      time.Time
+	regielekiTimes    []int64
+	regielekiSeconds  []int
+	rayquazaTime      int64
+	rayquazaSeconds   int
+}
+
+type event struct {
+	Type     string `json:"type"`
+	ID       int    `json:"id"`
+	Time     int64  `json:"time"`
+	Clock    string `json:"clock"`
+	Value    int    `json:"value"`
+	Vetoed   bool   `json:"vetoed"`
+	Verified bool   `json:"verified"`
+
+	state.Payload
 }
 
 type info struct {
-	*game
+	*store
 
 	tx       int
 	requests int
@@ -58,6 +89,8 @@ type objective struct {
 	Name string `json:"name"`
 	Team string `json:"team"`
 	Time int64  `json:"time"`
+
+	seconds int
 }
 
 type score struct {
@@ -67,23 +100,35 @@ type score struct {
 }
 
 var current = &info{
-	game:    reset(),
+	store:   newStore(),
 	clients: map[string]time.Time{},
 	mutex:   &sync.Mutex{},
 }
 
 func Bottom() []objective {
-	return current.game.Bottom
+	return current.snapshot().Bottom
 }
 
 func Clear() {
-	started := current.game.Started
-	current.game = reset()
-	current.game.Started = started
+	current.write(func(g *game) {
+		started := g.Started
+		*g = *reset()
+		g.Started = started
+	})
+}
+
+func ClearRayquaza() {
+	current.write(func(g *game) {
+		g.Rayquaza = ""
+		g.rayquazaTime = 0
+		g.rayquazaSeconds = 0
+	})
 }
 
 func Clock() string {
-	return fmt.Sprintf("%02d:%02d", current.game.Seconds/60, current.game.Seconds%60)
+	c := ""
+	current.read(func(g *game) { c = g.clock() })
+	return c
 }
 
 func Clients() int {
@@ -91,7 +136,7 @@ func Clients() int {
 	defer current.mutex.Unlock()
 
 	for c := range current.clients {
-		if time.Since(current.clients[c]) > time.Second*5 {
+		if time.Since(current.clients[c]) > idle {
 			notify.Feed(nrgba.Slate, "Client %s has disconnected", c)
 			delete(current.clients, c)
 		}
@@ -101,89 +146,84 @@ func Clients() int {
 }
 
 func Holding() int {
-	return current.game.Energy
+	e := 0
+	current.read(func(g *game) { e = g.Energy })
+	return e
 }
 
 func IsFinalStretch() bool {
-	if current.game.Seconds == 0 || current.game.Seconds >= 130 {
-		return false
-	}
-
-	// Edge case to handle scoring at exactly 2:00 and missing time update.
-	if time.Since(current.lastSecondsUpdate).Seconds() >= float64(current.game.Seconds-130) {
-		return true
-	}
-
-	return current.game.Seconds > 0 && current.game.Seconds < 121
+	f := false
+	current.read(func(g *game) { f = g.finalStretch() })
+	return f
 }
 
 func KOs(t *team.Team) int {
-	switch t.Name {
-	case team.Purple.Name:
-		return current.game.Purple.KOs
-	case team.Orange.Name:
-		return current.game.Orange.KOs
-	default:
-		return 0
-	}
+	kos := 0
+	current.read(func(g *game) {
+		switch t.Name {
+		case team.Purple.Name:
+			kos = g.Purple.KOs
+		case team.Orange.Name:
+			kos = g.Orange.KOs
+		}
+	})
+	return kos
 }
 
 func Listen() error {
-	http.Handle("/ws", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-			OriginPatterns:     []string{"127.0.0.1", "localhost", "0.0.0.0"},
-			InsecureSkipVerify: true,
-		})
-		if err != nil {
-			notify.Error("Server failed to accept websocket connection (%v)", err)
-			return
-		}
-		defer c.Close(websocket.StatusNormalClosure, "cross origin WebSocket accepted")
-
-		current.game.Profile = config.Current.Profile
-		current.game.Events = state.Strings(time.Second * 5)
+	http.HandleFunc("/ws", stream)
+	http.HandleFunc("/events", events)
 
-		raw, err := json.Marshal(current.game)
+	http.HandleFunc("/http", func(w http.ResponseWriter, r *http.Request) {
+		raw, err := marshal()
 		if err != nil {
 			notify.Error("Server failed to create server response (%v)", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		err = c.Write(context.Background(), websocket.MessageText, raw)
+		_, err = w.Write(raw)
 		if err != nil {
 			notify.Error("Server failed to send server response (%v)", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		current.client(r, "/ws", raw)
-
-		current.tx += len(raw)
-		current.requests++
-	}))
-
-	http.HandleFunc("/http", func(w http.ResponseWriter, r *http.Request) {
-		current.game.Profile = config.Current.Profile
-		current.game.Events = state.Strings(time.Second * 5)
+		current.client(r, "/http", raw)
+	})
 
-		raw, err := json.Marshal(current.game)
+	http.HandleFunc("/v2/state", func(w http.ResponseWriter, r *http.Request) {
+		raw, err := marshalV2()
 		if err != nil {
 			notify.Error("Server failed to create server response (%v)", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
+
 		_, err = w.Write(raw)
 		if err != nil {
 			notify.Error("Server failed to send server response (%v)", err)
-			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		current.client(r, "/http", raw)
-		current.tx += len(raw)
-		current.requests++
+		current.client(r, "/v2/state", raw)
+	})
+
+	control()
+	matches()
+	detectors()
+	reviews()
+
+	http.HandleFunc("/schema", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json")
+
+		_, err := w.Write([]byte(schemaV2))
+		if err != nil {
+			notify.Error("Server failed to send server response (%v)", err)
+			return
+		}
 	})
 
 	go func() {
@@ -192,15 +232,16 @@ func Listen() error {
 		for {
 			time.Sleep(time.Minute)
 
-			if current.requests < 1 {
+			avg := current.average()
+			if avg < 0 {
 				continue
 			}
 
-			diff := float64(last - (current.tx / current.requests))
+			diff := float64(last - avg)
 			if math.Abs(diff) < 10 {
 				continue
 			}
-			last = current.tx / current.requests
+			last = avg
 
 			notify.System("Server is sending an average of %d bytes per request", last)
 		}
@@ -219,26 +260,48 @@ func Listen() error {
 }
 
 func Match() bool {
-	return current.game.Match
+	m := false
+	current.read(func(g *game) { m = g.Match })
+	return m
+}
+
+// MatchStarted returns the time the current match started, or zero.
+func MatchStarted() time.Time {
+	t := time.Time{}
+	current.read(func(g *game) { t = g.matchStarted })
+	return t
 }
 
 func Objectives(t *team.Team) (regielekis, regices, regirocks, registeels, rayquazas int) {
-	q := 0
-	if current.game.Rayquaza == t.Name {
-		q++
-	}
-	return RegielekisSecured(t), RegicesSecured(t), RegirocksSecured(t), RegisteelsSecured(t), q
+	current.read(func(g *game) {
+		if g.Rayquaza == t.Name {
+			rayquazas++
+		}
+
+		for _, r := range g.Regilekis {
+			if r == t.Name {
+				regielekis++
+			}
+		}
+
+		regices = g.secured("regice", t.Name)
+		regirocks = g.secured("regirock", t.Name)
+		registeels = g.secured("registeel", t.Name)
+	})
+	return
 }
 
 func Rayquaza() string {
-	return current.game.Rayquaza
+	r := ""
+	current.read(func(g *game) { r = g.Rayquaza })
+	return r
 }
 
 func RegielekiAdv() *team.Team {
 	p := 0
 	o := 0
 
-	for _, t := range current.game.Regilekis {
+	for _, t := range Regielekis() {
 		switch t {
 		case team.Purple.Name:
 			p++
@@ -259,21 +322,17 @@ func RegielekiAdv() *team.Team {
 
 func RegicesSecured(t *team.Team) int {
 	n := 0
-	for _, b := range current.game.Bottom {
-		if b.Name == "regice" && b.Team == t.Name {
-			n++
-		}
-	}
+	current.read(func(g *game) { n = g.secured("regice", t.Name) })
 	return n
 }
 
 func Regielekis() []string {
-	return current.game.Regilekis
+	return current.snapshot().Regilekis
 }
 
 func RegielekisSecured(t *team.Team) int {
 	n := 0
-	for _, r := range current.game.Regilekis {
+	for _, r := range Regielekis() {
 		if r == t.Name {
 			n++
 		}
@@ -283,43 +342,42 @@ func RegielekisSecured(t *team.Team) int {
 
 func RegirocksSecured(t *team.Team) int {
 	n := 0
-	for _, b := range current.game.Bottom {
-		if b.Name == "regirock" && b.Team == t.Name {
-			n++
-		}
-	}
+	current.read(func(g *game) { n = g.secured("regirock", t.Name) })
 	return n
 }
 
 func RegisteelsSecured(t *team.Team) int {
 	n := 0
-	for _, b := range current.game.Bottom {
-		if b.Name == "registeel" && b.Team == t.Name {
-			n++
-		}
-	}
+	current.read(func(g *game) { n = g.secured("registeel", t.Name) })
 	return n
 }
 
 func Score(t *team.Team) int {
-	switch t {
-	case team.Purple:
-		return current.game.Purple.Value
-	case team.Orange:
-		return current.game.Orange.Value
-	case team.Self:
-		return current.game.Self.Value
-	default:
-		return -1
-	}
+	v := -1
+	current.read(func(g *game) {
+		switch t {
+		case team.Purple:
+			v = g.Purple.Value
+		case team.Orange:
+			v = g.Orange.Value
+		case team.Self:
+			v = g.Self.Value
+		}
+	})
+	return v
 }
 
 func Scores() (orange, purple, self int) {
-	return current.game.Orange.Value, current.game.Purple.Value, current.game.Self.Value
+	current.read(func(g *game) {
+		orange, purple, self = g.Orange.Value, g.Purple.Value, g.Self.Value
+	})
+	return
 }
 
 func Seconds() int {
-	return current.game.Seconds
+	s := 0
+	current.read(func(g *game) { s = g.Seconds })
+	return s
 }
 
 func SetBottomObjective(t *team.Team, name string, n int) {
@@ -331,123 +389,157 @@ func SetBottomObjective(t *team.Team, name string, n int) {
 
 	op := fmt.Sprintf("[%s] %s #%d", strings.Title(t.Name), strings.Title(o.Name), n+1)
 
-	switch {
-	// Illegal.
-	case len(current.Bottom) < n:
-		notify.Warn("[Control] %s illegal operation (no index)", op)
-
-	// Remove.
-	case len(current.Bottom) == n+1 && current.Bottom[n].Team == t.Name && current.Bottom[n].Name == o.Name:
-		// Remove last objective.
-		current.Bottom = current.Bottom[:n]
-		notify.Unique(t.NRGBA, "[Control] %s removed", op)
-
-	// Add.
-	case len(current.Bottom) == n:
-		current.Bottom = append(current.Bottom, o)
-		notify.Unique(t.NRGBA, "[Control] %s secured", op)
-	case len(current.Bottom) > n+1 && current.Bottom[n].Team != t.Name:
-		current.Bottom[n] = o
-		notify.Unique(t.NRGBA, "[Control] %s secure replaced", op)
-
-		// Overwrite.
-	case len(current.Bottom) == n+1 && current.Bottom[n].Team == t.Name && current.Bottom[n].Name != o.Name:
-		// Replace between first and last.
-		fallthrough
-	case len(current.Bottom) > n+1 && current.Bottom[n].Team == t.Name:
-		fallthrough
-	case len(current.Bottom) == n+1 && current.Bottom[n].Team != t.Name:
-		// Overwrite last objective.
-		current.Bottom[n] = o
-		notify.Unique(t.NRGBA, "[Control] %s secure replaced", op)
-	}
+	current.write(func(g *game) {
+		o.seconds = g.Seconds
+
+		switch {
+		// Illegal.
+		case len(g.Bottom) < n:
+			notify.Warn("[Control] %s illegal operation (no index)", op)
+
+		// Remove.
+		case len(g.Bottom) == n+1 && g.Bottom[n].Team == t.Name && g.Bottom[n].Name == o.Name:
+			// Remove last objective.
+			g.Bottom = g.Bottom[:n]
+			notify.Unique(t.NRGBA, "[Control] %s removed", op)
+
+		// Add.
+		case len(g.Bottom) == n:
+			g.Bottom = append(g.Bottom, o)
+			notify.Unique(t.NRGBA, "[Control] %s secured", op)
+		case len(g.Bottom) > n+1 && g.Bottom[n].Team != t.Name:
+			g.Bottom[n] = o
+			notify.Unique(t.NRGBA, "[Control] %s secure replaced", op)
+
+			// Overwrite.
+		case len(g.Bottom) == n+1 && g.Bottom[n].Team == t.Name && g.Bottom[n].Name != o.Name:
+			// Replace between first and last.
+			fallthrough
+		case len(g.Bottom) > n+1 && g.Bottom[n].Team == t.Name:
+			fallthrough
+		case len(g.Bottom) == n+1 && g.Bottom[n].Team != t.Name:
+			// Overwrite last objective.
+			g.Bottom[n] = o
+			notify.Unique(t.NRGBA, "[Control] %s secure replaced", op)
+		}
+	})
 }
 
 func SetConfig(c bool) {
-	current.game.Config = c
+	current.write(func(g *game) { g.Config = c })
 }
 
 func SetDefeated() {
-	current.game.Defeated = append(current.game.Defeated, current.game.Seconds)
+	current.write(func(g *game) { g.Defeated = append(g.Defeated, g.Seconds) })
 }
 
 func SetEnergy(b int) {
-	current.game.Energy = b
+	current.write(func(g *game) { g.Energy = b })
 }
 
 func SetKO(t *team.Team) {
-	switch t.Name {
-	case team.Purple.Name:
-		current.game.Purple.KOs++
-	case team.Orange.Name:
-		current.game.Orange.KOs++
-	}
+	current.write(func(g *game) {
+		switch t.Name {
+		case team.Purple.Name:
+			g.Purple.KOs++
+		case team.Orange.Name:
+			g.Orange.KOs++
+		}
+	})
 }
 
 func SetMatchStarted() {
-	current.game.Match = true
+	current.write(func(g *game) {
+		g.Match = true
+		g.matchStarted = time.Now()
+	})
 }
 
 func SetMatchStopped() {
-	current.game.Match = false
+	current.write(func(g *game) { g.Match = false })
 }
 
 func SetRayquaza(t *team.Team) {
-	current.game.Rayquaza = t.Name
+	current.write(func(g *game) {
+		g.Rayquaza = t.Name
+		g.rayquazaTime = time.Now().Unix()
+		g.rayquazaSeconds = g.Seconds
+	})
 }
 
-func SetRegice(t *team.Team) {
-	current.Bottom = append(current.Bottom, objective{
-		Team: t.Name,
-		Name: "regice",
-		Time: time.Now().Unix(),
+// SetRegielekiAt assumes n to be an index starting at 0.
+func SetRegielekiAt(t *team.Team, n int) {
+	op := fmt.Sprintf("[%s] %s #%d", strings.Title(t.Name), strings.Title(config.Current.Rules().Top().Name), n+1)
+
+	current.write(func(g *game) {
+		switch {
+		case n < 0 || n >= len(g.Regilekis):
+			notify.Warn("[Control] %s illegal operation (%d slots)", op, len(g.Regilekis))
+		case n != 0 && g.Regilekis[n-1] == team.None.Name:
+			notify.Warn("[Control] %s illegal operation (missing previous)", op)
+		case g.Regilekis[n] != t.Name:
+			notify.Unique(t.NRGBA, "[Control] %s secure replaced", op)
+			g.Regilekis[n] = t.Name
+			g.regielekiTimes[n] = time.Now().Unix()
+			g.regielekiSeconds[n] = g.Seconds
+		case n+1 == len(g.Regilekis) || g.Regilekis[n+1] == team.None.Name:
+			notify.Unique(t.NRGBA, "[Control] %s reset", op)
+			g.Regilekis[n] = team.None.Name
+			g.regielekiTimes[n] = 0
+			g.regielekiSeconds[n] = 0
+		default:
+			notify.Warn("[Control] %s illegal operation", op)
+		}
 	})
 }
 
-func SetRegieleki(t *team.Team) {
-	for i, t2 := range current.Regilekis {
-		if t2 == team.None.Name {
-			current.game.Regilekis[i] = t.Name
-			return
-		}
+// SetSecured records an objective secured by a team. Top lane objectives fill the next free slot, or
+// restart from the first slot when every slot is taken.
+func SetSecured(t *team.Team, name string) {
+	o, ok := config.Current.Rules().Objective(name)
+	if !ok {
+		notify.Error("Server received an unknown objective \"%s\"", name)
+		return
 	}
 
-	current.game.Regilekis[0] = t.Name
-	current.game.Regilekis[1] = team.None.Name
-	current.game.Regilekis[2] = team.None.Name
-}
+	if o.Lane == config.LaneMiddle {
+		SetRayquaza(t)
+		return
+	}
 
-// SetRegielekiAt assumes n to be an index starting at 0.
-func SetRegielekiAt(t *team.Team, n int) {
-	op := fmt.Sprintf("[%s] Regieleki #%d", strings.Title(t.Name), n+1)
+	current.write(func(g *game) {
+		if o.Lane == config.LaneBottom {
+			g.Bottom = append(g.Bottom, objective{
+				Team:    t.Name,
+				Name:    o.Name,
+				Time:    time.Now().Unix(),
+				seconds: g.Seconds,
+			})
+			return
+		}
 
-	switch {
-	case n != 0 && current.game.Regilekis[n-1] == team.None.Name:
-		notify.Warn("[Control] %s illegal operation (missing previous)", op)
-	case current.game.Regilekis[n] != t.Name:
-		notify.Unique(t.NRGBA, "[Control] %s secure replaced", op)
-		current.game.Regilekis[n] = t.Name
-	case n+1 == len(current.game.Regilekis) || current.game.Regilekis[n+1] == team.None.Name:
-		notify.Unique(t.NRGBA, "[Control] %s reset", op)
-		current.game.Regilekis[n] = team.None.Name
-	default:
-		notify.Warn("[Control] %s illegal operation", op)
-	}
-}
+		if len(g.Regilekis) == 0 {
+			return
+		}
 
-func SetRegirock(t *team.Team) {
-	current.Bottom = append(current.Bottom, objective{
-		Team: t.Name,
-		Name: "regirock",
-		Time: time.Now().Unix(),
-	})
-}
+		for i, t2 := range g.Regilekis {
+			if t2 == team.None.Name {
+				g.Regilekis[i] = t.Name
+				g.regielekiTimes[i] = time.Now().Unix()
+				g.regielekiSeconds[i] = g.Seconds
+				return
+			}
+		}
 
-func SetRegisteel(t *team.Team) {
-	current.Bottom = append(current.Bottom, objective{
-		Team: t.Name,
-		Name: "registeel",
-		Time: time.Now().Unix(),
+		for i := range g.Regilekis {
+			g.Regilekis[i] = team.None.Name
+			g.regielekiTimes[i] = 0
+			g.regielekiSeconds[i] = 0
+		}
+
+		g.Regilekis[0] = t.Name
+		g.regielekiTimes[0] = time.Now().Unix()
+		g.regielekiSeconds[0] = g.Seconds
 	})
 }
 
@@ -457,52 +549,244 @@ func SetScore(t *team.Team, value int) {
 		Value: value,
 	}
 
-	switch t.Name {
-	case team.Purple.Name:
-		current.game.Purple.Value += s.Value
-	case team.Orange.Name:
-		current.game.Orange.Value += s.Value
-	case team.Self.Name:
-		current.game.Purple.Value += s.Value
-		current.game.Self.Value += s.Value
-		current.game.Stacks++
-	case team.First.Name:
-		switch team.First.Alias {
+	current.write(func(g *game) {
+		switch t.Name {
 		case team.Purple.Name:
-			current.game.Purple.Value += s.Value
+			g.Purple.Value += s.Value
 		case team.Orange.Name:
-			current.game.Orange.Value += s.Value
-		default:
-			notify.Error("Server received first goal from an unknown team")
+			g.Orange.Value += s.Value
+		case team.Self.Name:
+			g.Purple.Value += s.Value
+			g.Self.Value += s.Value
+			g.Stacks++
+		case team.First.Name:
+			switch team.First.Alias {
+			case team.Purple.Name:
+				g.Purple.Value += s.Value
+			case team.Orange.Name:
+				g.Orange.Value += s.Value
+			default:
+				notify.Error("Server received first goal from an unknown team")
+			}
 		}
+
+		g.estimate()
+	})
+}
+
+// adjust corrects the score of a team without counting a goal. Corrections of the self score also
+// correct the purple score.
+func adjust(name string, points int) error {
+	switch name {
+	case team.Purple.Name, team.Orange.Name, team.Self.Name:
+	default:
+		return fmt.Errorf("cannot adjust the score of team \"%s\"", name)
 	}
+
+	current.write(func(g *game) {
+		switch name {
+		case team.Purple.Name:
+			g.Purple.Value += points
+		case team.Orange.Name:
+			g.Orange.Value += points
+		case team.Self.Name:
+			g.Purple.Value += points
+			g.Self.Value += points
+		}
+
+		g.estimate()
+	})
+
+	return nil
 }
 
 func SetStarted() {
-	current.game.Started = true
+	current.write(func(g *game) { g.Started = true })
 	state.Add(state.ServerStarted, Clock(), -1)
 }
 
 func SetStopped() {
-	current.game.Started = false
+	current.write(func(g *game) { g.Started = false })
 	state.Add(state.ServerStopped, Clock(), -1)
 }
 
 func SetTime(minutes, seconds int) {
-	current.game.lastSecondsUpdate = time.Now()
+	current.write(func(g *game) {
+		g.lastSecondsUpdate = time.Now()
+
+		if minutes+seconds == 0 {
+			g.Match = false
+			return
+		}
+
+		g.Match = true
+
+		g.Seconds = minutes*60 + seconds
+
+		// Matches joined after they started are estimated to have started when their clock was full.
+		if g.matchStarted.IsZero() {
+			elapsed := config.Current.Rules().Match - g.Seconds
+			if elapsed < 0 {
+				elapsed = 0
+			}
+			g.matchStarted = time.Now().Add(-time.Duration(elapsed) * time.Second)
+		}
+	})
+}
+
+func Started() bool {
+	s := false
+	current.read(func(g *game) { s = g.Started })
+	return s
+}
+
+// stream keeps a websocket connection open and sends the game every time it is modified, or
+// every heartbeat when it is not.
+func stream(w http.ResponseWriter, r *http.Request) {
+	c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns:     []string{"127.0.0.1", "localhost", "0.0.0.0"},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		notify.Error("Server failed to accept websocket connection (%v)", err)
+		return
+	}
+	defer c.Close(websocket.StatusNormalClosure, "cross origin WebSocket closed")
+
+	// Clients are not expected to send data, CloseRead handles control frames for us.
+	ctx := c.CloseRead(r.Context())
+
+	updates := current.subscribe()
+	defer current.unsubscribe(updates)
+
+	tick := time.NewTicker(heartbeat)
+	defer tick.Stop()
+
+	last := []byte{}
+
+	for {
+		raw, err := marshal()
+		if err != nil {
+			notify.Error("Server failed to create server response (%v)", err)
+			return
+		}
+
+		if !bytes.Equal(raw, last) {
+			err = write(ctx, c, raw)
+			if err != nil {
+				notify.Warn("Server closed a slow %s connection (%v)", "/ws", err)
+				return
+			}
+
+			last = raw
+		} else {
+			pctx, cancel := context.WithTimeout(ctx, deadline)
+			err = c.Ping(pctx)
+			cancel()
+			if err != nil {
+				notify.Feed(nrgba.Slate, "Server lost a %s connection (%v)", "/ws", err)
+				return
+			}
+		}
+
+		current.client(r, "/ws", raw)
 
-	if minutes+seconds == 0 {
-		current.game.Match = false
+		select {
+		case <-ctx.Done():
+			return
+		case <-updates:
+		case <-tick.C:
+		}
+	}
+}
+
+// events keeps an http connection open and sends server-sent events for every state event as it
+// occurs, and the game every time it is modified or every heartbeat when it is not.
+func events(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		notify.Error("Server failed to accept %s connection (streaming unsupported)", "/events")
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	current.game.Match = true
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	updates := current.subscribe()
+	defer current.unsubscribe(updates)
+
+	tick := time.NewTicker(heartbeat)
+	defer tick.Stop()
+
+	added := state.Subscribe(state.Query{}, 64)
+	defer added.Close()
+
+	send := func(name string, v interface{}) error {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, raw)
+		if err != nil {
+			return err
+		}
+		f.Flush()
+
+		current.client(r, "/events", raw)
+
+		return nil
+	}
+
+	snapshot := func() error {
+		raw, err := marshal()
+		if err != nil {
+			return err
+		}
+		return send("game", json.RawMessage(raw))
+	}
 
-	current.game.Seconds = minutes*60 + seconds
+	err := snapshot()
+	if err != nil {
+		notify.Error("Server failed to send %s response (%v)", "/events", err)
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-added.Events:
+			err = send("event", event{
+				Type:     e.EventType.String(),
+				ID:       e.ID(),
+				Time:     e.Time.Unix(),
+				Clock:    e.Clock,
+				Value:    e.Value,
+				Vetoed:   e.Vetoed,
+				Verified: e.Verified,
+				Payload:  e.Payload,
+			})
+		case <-updates:
+			err = snapshot()
+		case <-tick.C:
+			err = snapshot()
+		}
+		if err != nil {
+			notify.Feed(nrgba.Slate, "Server lost a %s connection (%v)", "/events", err)
+			return
+		}
+	}
 }
 
-func Started() bool {
-	return current.game.Started
+func write(ctx context.Context, c *websocket.Conn, raw []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	return c.Write(ctx, websocket.MessageText, raw)
 }
 
 func (i *info) client(r *http.Request, route string, raw []byte) {
@@ -519,9 +803,38 @@ func (i *info) client(r *http.Request, route string, raw []byte) {
 	}
 
 	i.clients[key] = time.Now()
+
+	i.tx += len(raw)
+	i.requests++
+}
+
+// average returns the average number of bytes sent per request, or -1 when no requests were made.
+func (i *info) average() int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.requests < 1 {
+		return -1
+	}
+
+	return i.tx / i.requests
+}
+
+func marshal() ([]byte, error) {
+	g := current.snapshot()
+	g.Profile = config.Current.Profile
+	g.Events = state.Strings(time.Second * 5)
+	g.Spawns = g.spawns()
+
+	return json.Marshal(g)
 }
 
 func reset() *game {
+	regielekis := []string{}
+	for i := 0; i < config.Current.Rules().Top().Slots; i++ {
+		regielekis = append(regielekis, team.None.Name)
+	}
+
 	return &game{
 		Purple: &score{
 			Team:  team.Purple.Name,
@@ -535,12 +848,16 @@ func reset() *game {
 			Team:  team.Self.Name,
 			Value: 0,
 		},
-		Seconds:   0,
-		Energy:    0,
-		Regilekis: []string{team.None.Name, team.None.Name, team.None.Name},
-		Rayquaza:  "",
-		Bottom:    []objective{},
-		Version:   global.Version,
-		Defeated:  []int{},
+		Seconds:          0,
+		Energy:           0,
+		Regilekis:        regielekis,
+		regielekiTimes:   make([]int64, len(regielekis)),
+		regielekiSeconds: make([]int, len(regielekis)),
+		Spawns:           []Spawn{},
+		Rayquaza:         "",
+		Bottom:           []objective{},
+		Version:          global.Version,
+		Defeated:         []int{},
+		Goals:            goals{Purple: newGoals(), Orange: newGoals()},
 	}
 }
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/team"
+)
+
+// Spawn is the next spawn of an objective.
+type Spawn struct {
+	Name    string `json:"name"`
+	Seconds int    `json:"seconds"` // Seconds until the objective spawns, 0 when it has spawned.
+	Clock   string `json:"clock"`   // Match clock of the spawn.
+}
+
+// spawns returns the next spawn of every objective, from the match clock and the last time each objective
+// was secured. Objectives that will not spawn again, or whose last secure can not be placed on the match
+// clock, are omitted.
+func (g *game) spawns() []Spawn {
+	now := g.Seconds
+	if now == 0 && !g.Match {
+		now = config.Current.Rules().Match
+	}
+
+	spawns := []Spawn{}
+
+	for _, s := range config.Current.Spawns() {
+		at := s.First
+
+		secured, ok := g.securedAt(s.Secures)
+		if !ok {
+			continue
+		}
+
+		if secured > 0 {
+			if s.Respawn == 0 {
+				continue
+			}
+			at = secured - s.Respawn
+		}
+
+		if at < s.Until {
+			continue
+		}
+
+		remaining := now - at
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		spawns = append(spawns, Spawn{
+			Name:    s.Name,
+			Seconds: remaining,
+			Clock:   fmt.Sprintf("%02d:%02d", at/60, at%60),
+		})
+	}
+
+	return spawns
+}
+
+// securedAt returns the match clock of the last secure of any of the objectives, or 0 when none were
+// secured. Secures made while the match clock was unknown are placed on the clock from the time they were
+// made, and false is returned when the match start is unknown as well.
+func (g *game) securedAt(names []string) (int, bool) {
+	r := config.Current.Rules()
+
+	at, ok := 0, true
+
+	last := func(name string, seconds int, secured int64) {
+		for _, n := range names {
+			if n != name {
+				continue
+			}
+
+			if seconds == 0 {
+				seconds = g.clockAt(secured)
+			}
+
+			switch {
+			case seconds <= 0:
+				ok = false
+			case at == 0 || seconds < at:
+				at = seconds
+			}
+		}
+	}
+
+	for i, t := range g.Regilekis {
+		if t != team.None.Name {
+			last(r.Top().Name, g.regielekiSeconds[i], g.regielekiTimes[i])
+		}
+	}
+
+	for _, o := range g.Bottom {
+		last(o.Name, o.seconds, o.Time)
+	}
+
+	if g.Rayquaza != "" {
+		last(r.Middle().Name, g.rayquazaSeconds, g.rayquazaTime)
+	}
+
+	return at, ok
+}
+
+// clockAt returns the match clock at a unix time, or 0 when the match start is unknown.
+func (g *game) clockAt(unix int64) int {
+	if g.matchStarted.IsZero() || unix == 0 {
+		return 0
+	}
+
+	seconds := config.Current.Rules().Match - int(unix-g.matchStarted.Unix())
+	if seconds < 0 {
+		return 0
+	}
+
+	return seconds
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pidgy/unitehud/config"
+)
+
+// store guards the game from concurrent detection routines and server clients. Mutations are made
+// while holding the write lock, and streaming clients are notified once it is released. Readers
+// outside of the store should use a snapshot.
+type store struct {
+	game  *game
+	mutex *sync.RWMutex
+
+	streams      map[chan bool]bool
+	streamsMutex *sync.Mutex
+}
+
+func newStore() *store {
+	return &store{
+		game:         reset(),
+		mutex:        &sync.RWMutex{},
+		streams:      map[chan bool]bool{},
+		streamsMutex: &sync.Mutex{},
+	}
+}
+
+// read calls fn while holding the read lock, fn must not modify or retain the game.
+func (s *store) read(fn func(g *game)) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	fn(s.game)
+}
+
+// snapshot returns a copy of the game that is safe to modify and retain.
+func (s *store) snapshot() *game {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.game.copy()
+}
+
+// write calls fn while holding the write lock, and notifies streaming clients when it returns.
+func (s *store) write(fn func(g *game)) {
+	defer s.push()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fn(s.game)
+}
+
+// push signals every streaming client that the game has changed. Clients with an update already
+// pending are skipped, slow clients will receive the latest snapshot once they catch up.
+func (s *store) push() {
+	s.streamsMutex.Lock()
+	defer s.streamsMutex.Unlock()
+
+	for c := range s.streams {
+		select {
+		case c <- true:
+		default:
+		}
+	}
+}
+
+func (s *store) subscribe() chan bool {
+	s.streamsMutex.Lock()
+	defer s.streamsMutex.Unlock()
+
+	c := make(chan bool, 1)
+	s.streams[c] = true
+
+	return c
+}
+
+func (s *store) unsubscribe(c chan bool) {
+	s.streamsMutex.Lock()
+	defer s.streamsMutex.Unlock()
+
+	delete(s.streams, c)
+}
+
+func (g *game) clock() string {
+	return fmt.Sprintf("%02d:%02d", g.Seconds/60, g.Seconds%60)
+}
+
+func (g *game) copy() *game {
+	c := *g
+
+	c.Bottom = append([]objective{}, g.Bottom...)
+	c.Defeated = append([]int{}, g.Defeated...)
+	c.Events = append([]string{}, g.Events...)
+	c.Goals.Purple = append([]Goal{}, g.Goals.Purple...)
+	c.Goals.Orange = append([]Goal{}, g.Goals.Orange...)
+	c.Regilekis = append([]string{}, g.Regilekis...)
+	c.regielekiTimes = append([]int64{}, g.regielekiTimes...)
+	c.regielekiSeconds = append([]int{}, g.regielekiSeconds...)
+	c.Spawns = append([]Spawn{}, g.Spawns...)
+
+	purple, orange, self := *g.Purple, *g.Orange, *g.Self
+	c.Purple, c.Orange, c.Self = &purple, &orange, &self
+
+	return &c
+}
+
+func (g *game) finalStretch() bool {
+	start := config.Current.Rules().FinalStretch
+
+	if g.Seconds == 0 || g.Seconds >= start+10 {
+		return false
+	}
+
+	// Edge case to handle scoring at exactly the start of the final stretch and missing time update.
+	if time.Since(g.lastSecondsUpdate).Seconds() >= float64(g.Seconds-(start+10)) {
+		return true
+	}
+
+	return g.Seconds > 0 && g.Seconds < start+1
+}
+
+func (g *game) secured(name string, t string) int {
+	n := 0
+	for _, b := range g.Bottom {
+		if b.Name == name && b.Team == t {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/pidgy/unitehud/team"
+)
+
+// TestConcurrentDetectors updates the game from several detectors while clients marshal, snapshot and
+// stream it, run with -race. Scores only increase, so every payload must be at least the previous one.
+func TestConcurrentDetectors(t *testing.T) {
+	Clear()
+	defer Clear()
+
+	const (
+		detectors = 4
+		updates   = 200
+	)
+
+	wg := &sync.WaitGroup{}
+	done := make(chan bool)
+
+	for d := 0; d < detectors; d++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < updates; i++ {
+				SetScore(team.Purple, 2)
+				SetScore(team.Orange, 3)
+				SetScore(team.Self, 1)
+				SetKO(team.Purple)
+				SetKO(team.Orange)
+				SetTime(5, i%60)
+				SetSecured(team.Purple, "regieleki")
+				SetSecured(team.Orange, "regice")
+				SetGoals(team.Orange, []Goal{{Tier: 1, Lane: "top", Match: .95, Destroyed: i%2 == 0}})
+			}
+		}()
+	}
+
+	readers := &sync.WaitGroup{}
+	readers.Add(3)
+
+	errs := make(chan error, 3)
+
+	go func() {
+		defer readers.Done()
+
+		last := stateV2{}
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			raw, err := marshalV2()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			s := stateV2{}
+			err = json.Unmarshal(raw, &s)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if s.Purple.Score < last.Purple.Score || s.Orange.Score < last.Orange.Score || s.Self.Score < last.Self.Score {
+				t.Errorf("scores went back from %+v to %+v", last, s)
+			}
+
+			if s.Self.Score > s.Purple.Score {
+				t.Errorf("self score %d is greater than purple score %d", s.Self.Score, s.Purple.Score)
+			}
+
+			last = s
+		}
+	}()
+
+	go func() {
+		defer readers.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			raw, err := marshal()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			g := game{}
+			err = json.Unmarshal(raw, &g)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			s := current.snapshot()
+			s.Purple.Value = -1
+			s.Bottom = append(s.Bottom, objective{})
+		}
+	}()
+
+	go func() {
+		defer readers.Done()
+
+		c := current.subscribe()
+		defer current.unsubscribe(c)
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+				current.read(func(g *game) { _ = g.Purple.Value })
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	select {
+	case err := <-errs:
+		t.Fatal(err)
+	default:
+	}
+
+	o, p, self := Scores()
+	if p != detectors*updates*3 || o != detectors*updates*3 || self != detectors*updates {
+		t.Fatalf("expected scores %d/%d/%d, got %d/%d/%d",
+			detectors*updates*3, detectors*updates*3, detectors*updates, p, o, self)
+	}
+
+	if KOs(team.Purple) != detectors*updates || KOs(team.Orange) != detectors*updates {
+		t.Fatalf("expected %d KOs per team, got %d/%d", detectors*updates, KOs(team.Purple), KOs(team.Orange))
+	}
+
+	s := current.snapshot()
+	if s.Stacks != detectors*updates {
+		t.Fatalf("expected %d stacks, got %d", detectors*updates, s.Stacks)
+	}
+
+	if s.Purple.Value < 0 || len(s.Bottom) != detectors*updates {
+		t.Fatalf("snapshots modified the game")
+	}
+}
@@ -0,0 +1,82 @@
+package state
+
+import "sync"
+
+// Subscription receives a copy of every event added after it was created that its query selects. Events
+// are dropped instead of blocking Add when the subscriber falls behind by more than its buffer.
+type Subscription struct {
+	Events <-chan *Event
+
+	query   Query
+	events  chan *Event
+	dropped int
+}
+
+var bus = struct {
+	subscriptions map[*Subscription]bool
+
+	mutex *sync.Mutex
+}{
+	subscriptions: map[*Subscription]bool{},
+	mutex:         &sync.Mutex{},
+}
+
+// Subscribe returns a subscription to events selected by a query, ignoring Since, buffering up to
+// buffer events.
+func Subscribe(q Query, buffer int) *Subscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+
+	s := &Subscription{
+		query:  q,
+		events: make(chan *Event, buffer),
+	}
+	s.Events = s.events
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.subscriptions[s] = true
+
+	return s
+}
+
+// Close stops and closes the subscription.
+func (s *Subscription) Close() {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	if !bus.subscriptions[s] {
+		return
+	}
+
+	delete(bus.subscriptions, s)
+	close(s.events)
+}
+
+// Dropped returns the number of events dropped because the buffer of the subscription was full.
+func (s *Subscription) Dropped() int {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	return s.dropped
+}
+
+// publish sends an event to every subscription that selects it.
+func publish(e *Event) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	for s := range bus.subscriptions {
+		if !s.query.Match(e) {
+			continue
+		}
+
+		select {
+		case s.events <- e.copy():
+		default:
+			s.dropped++
+		}
+	}
+}
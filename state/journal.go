@@ -0,0 +1,214 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pidgy/unitehud/notify"
+)
+
+// Journal is the directory of event journals, one file of JSON encoded entries per match. Entries are
+// appended as events are added or updated, and later entries of an event replace earlier ones. The journal
+// of a match is kept open until Clear, and synced to disk when the match ends. Events are not journaled
+// when Journal is empty.
+var Journal = "journal"
+
+type entry struct {
+	ID       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	Clock    string    `json:"clock"`
+	Type     int       `json:"type"`
+	Name     string    `json:"name"`
+	Value    int       `json:"value"`
+	Verified bool      `json:"verified"`
+	Vetoed   bool      `json:"vetoed"`
+	Held     bool      `json:"held,omitempty"`
+
+	Payload
+}
+
+var journal = struct {
+	file string
+	*os.File
+
+	mutex *sync.Mutex
+}{
+	mutex: &sync.Mutex{},
+}
+
+// Journals returns the journal of every match, oldest first.
+func Journals() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(Journal, "*.events"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Load returns the events of a journal, ordered from newest to oldest like Events. A partially written
+// last entry is ignored, as left by a crash.
+func Load(file string) ([]*Event, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	byID := map[int]*Event{}
+	events := []*Event{}
+
+	d := json.NewDecoder(f)
+	for {
+		en := entry{}
+
+		err := d.Decode(&en)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			notify.Warn("Event journal %s is corrupted after %d events (%v)", file, len(events), err)
+			break
+		}
+
+		e, ok := byID[en.ID]
+		if !ok {
+			e = &Event{id: en.ID}
+			byID[en.ID] = e
+			events = append([]*Event{e}, events...)
+		}
+
+		en.apply(e)
+	}
+
+	return events, nil
+}
+
+// Restore replaces Events with the events of a journal.
+func Restore(file string) error {
+	loaded, err := Load(file)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	events = loaded
+	next = 0
+	for _, e := range events {
+		if e.id > next {
+			next = e.id
+		}
+	}
+
+	return nil
+}
+
+// Verify marks an event as verified.
+func (e *Event) Verify() {
+	update(e, func(e *Event) { e.Verified = true })
+}
+
+// Veto marks an event as vetoed.
+func (e *Event) Veto() {
+	update(e, func(e *Event) { e.Vetoed = true })
+}
+
+// record appends an event to the journal of the current match, starting a new journal for the first event
+// added after Clear.
+func record(e *Event) {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if Journal == "" {
+		return
+	}
+
+	if journal.File == nil {
+		err := os.MkdirAll(Journal, 0755)
+		if err != nil {
+			notify.Error("Failed to create event journal directory %s (%v)", Journal, err)
+			return
+		}
+
+		journal.file = filepath.Join(Journal, fmt.Sprintf("%s.events", e.Time.Format("20060102-150405.000")))
+
+		journal.File, err = os.OpenFile(journal.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			notify.Error("Failed to open event journal %s (%v)", journal.file, err)
+			return
+		}
+	}
+
+	raw, err := json.Marshal(e.entry())
+	if err != nil {
+		notify.Error("Failed to encode %s event for the journal (%v)", e.EventType, err)
+		return
+	}
+
+	_, err = journal.Write(append(raw, '\n'))
+	if err != nil {
+		notify.Error("Failed to write to event journal %s (%v)", journal.file, err)
+		return
+	}
+
+	if e.EventType == MatchEnding {
+		err = journal.Sync()
+		if err != nil {
+			notify.Error("Failed to sync event journal %s (%v)", journal.file, err)
+		}
+	}
+}
+
+// entry returns the JSON encoding of an event.
+func (e *Event) entry() entry {
+	return entry{
+		ID:       e.id,
+		Time:     e.Time,
+		Clock:    e.Clock,
+		Type:     e.EventType.Int(),
+		Name:     e.EventType.String(),
+		Value:    e.Value,
+		Verified: e.Verified,
+		Vetoed:   e.Vetoed,
+		Held:     e.Held,
+		Payload:  e.Payload,
+	}
+}
+
+// apply sets the fields of an event from an entry, other than its journal ID.
+func (en entry) apply(e *Event) {
+	e.EventType = EventType(en.Type)
+	e.Time = en.Time
+	e.Clock = en.Clock
+	e.Value = en.Value
+	e.Verified = en.Verified
+	e.Vetoed = en.Vetoed
+	e.Held = en.Held
+	e.Payload = en.Payload.derive(e.EventType, e.Value)
+}
+
+// rotate closes the journal of the current match.
+func rotate() {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if journal.File != nil {
+		err := journal.Close()
+		if err != nil {
+			notify.Error("Failed to close event journal %s (%v)", journal.file, err)
+		}
+	}
+
+	journal.file = ""
+	journal.File = nil
+}
@@ -0,0 +1,158 @@
+package state
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pidgy/unitehud/team"
+)
+
+// Payload describes what an event means, so consumers do not need to interpret event types. Team,
+// objective, points and streak are derived from the event type and value when they are not set.
+type Payload struct {
+	Team       string  `json:"team,omitempty"`
+	Objective  string  `json:"objective,omitempty"`
+	Points     int     `json:"points,omitempty"` // Negative when points are taken away, like overrides.
+	Streak     bool    `json:"streak,omitempty"`
+	Detector   string  `json:"detector,omitempty"`
+	Confidence float32 `json:"confidence,omitempty"`
+	Frame      int64   `json:"frame,omitempty"` // Capture time of the frame in unix milliseconds.
+}
+
+var (
+	teams = map[EventType]string{
+		PurpleBaseOpen:         team.Purple.Name,
+		PurpleBaseClosed:       team.Purple.Name,
+		PurpleScore:            team.Purple.Name,
+		PurpleScoreMissed:      team.Purple.Name,
+		ScoreAdjustedPurple:    team.Purple.Name,
+		ObjectiveReachedPurple: team.Purple.Name,
+		RegielekiSecurePurple:  team.Purple.Name,
+		RegiceSecurePurple:     team.Purple.Name,
+		RegirockSecurePurple:   team.Purple.Name,
+		RegisteelSecurePurple:  team.Purple.Name,
+		RayquazaSecurePurple:   team.Purple.Name,
+		KOPurple:               team.Purple.Name,
+		KOStreakPurple:         team.Purple.Name,
+		OrangeBaseOpen:         team.Orange.Name,
+		OrangeBaseClosed:       team.Orange.Name,
+		OrangeScore:            team.Orange.Name,
+		OrangeScoreMissed:      team.Orange.Name,
+		ScoreAdjustedOrange:    team.Orange.Name,
+		ObjectiveReachedOrange: team.Orange.Name,
+		RegielekiSecureOrange:  team.Orange.Name,
+		RegiceSecureOrange:     team.Orange.Name,
+		RegirockSecureOrange:   team.Orange.Name,
+		RegisteelSecureOrange:  team.Orange.Name,
+		RayquazaSecureOrange:   team.Orange.Name,
+		KOOrange:               team.Orange.Name,
+		KOStreakOrange:         team.Orange.Name,
+		FirstScored:            team.First.Name,
+		PreScore:               team.Self.Name,
+		PostScore:              team.Self.Name,
+		HoldingEnergy:          team.Self.Name,
+		PressButtonToScore:     team.Self.Name,
+		ScoreAdjustedSelf:      team.Self.Name,
+		Killed:                 team.Self.Name,
+		KilledWithPoints:       team.Self.Name,
+		KilledWithoutPoints:    team.Self.Name,
+	}
+
+	objectives = map[EventType]string{
+		RegielekiSecurePurple: "regieleki",
+		RegielekiSecureOrange: "regieleki",
+		RegiceSecurePurple:    "regice",
+		RegiceSecureOrange:    "regice",
+		RegirockSecurePurple:  "regirock",
+		RegirockSecureOrange:  "regirock",
+		RegisteelSecurePurple: "registeel",
+		RegisteelSecureOrange: "registeel",
+		RayquazaSecurePurple:  "rayquaza",
+		RayquazaSecureOrange:  "rayquaza",
+	}
+
+	scoring = Types{
+		PreScore,
+		PostScore,
+		HoldingEnergy,
+		PressButtonToScore,
+		PurpleScore,
+		OrangeScore,
+		FirstScored,
+		PurpleScoreMissed,
+		OrangeScoreMissed,
+		ScoreAdjustedPurple,
+		ScoreAdjustedOrange,
+		ScoreAdjustedSelf,
+		ScoreOverride,
+	}
+)
+
+// Team returns the name of the team an event type belongs to, or an empty string.
+func (e EventType) Team() string {
+	return teams[e]
+}
+
+// Objective returns the name of the objective secured by an event type, or an empty string.
+func (e EventType) Objective() string {
+	return objectives[e]
+}
+
+// Scoring returns true if the value of an event type is a number of points.
+func (e EventType) Scoring() bool {
+	return scoring.Has(e)
+}
+
+// Detected returns the payload of an event found by a detector in a frame captured at a given time.
+func Detected(detector string, confidence float32, frame time.Time) Payload {
+	return Payload{
+		Detector:   detector,
+		Confidence: confidence,
+		Frame:      frame.UnixNano() / int64(time.Millisecond),
+	}
+}
+
+// derive fills the fields of a payload that can be derived from an event type and value.
+func (p Payload) derive(e EventType, value int) Payload {
+	if p.Team == "" {
+		p.Team = e.Team()
+	}
+
+	if p.Objective == "" {
+		p.Objective = e.Objective()
+	}
+
+	if p.Points == 0 && e.Scoring() {
+		switch e {
+		case ScoreAdjustedPurple, ScoreAdjustedOrange, ScoreAdjustedSelf:
+			p.Points = value
+		default:
+			if value > 0 {
+				p.Points = value
+			}
+		}
+	}
+
+	if e == KOStreakPurple || e == KOStreakOrange {
+		p.Streak = true
+	}
+
+	return p
+}
+
+func (e *Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.entry())
+}
+
+func (e *Event) UnmarshalJSON(raw []byte) error {
+	en := entry{}
+
+	err := json.Unmarshal(raw, &en)
+	if err != nil {
+		return err
+	}
+
+	en.apply(e)
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package state
+
+import "time"
+
+// Query selects events by type and payload, zero values are ignored.
+type Query struct {
+	Types     Types
+	Team      string
+	Objective string
+	Detector  string
+	Since     time.Duration // Only events added within a duration.
+	From      time.Time     // Only events added at or after a time.
+
+	Unvetoed bool // Ignore vetoed events.
+	Verified bool // Only verified events.
+}
+
+// Events returns a copy of every event selected by a query, ordered from newest to oldest.
+func (q Query) Events() []*Event {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	selected := []*Event{}
+
+	for _, e := range events {
+		if q.expired(e) {
+			break
+		}
+
+		if q.Match(e) {
+			selected = append(selected, e.copy())
+		}
+	}
+
+	return selected
+}
+
+// Count returns the number of events selected by a query.
+func (q Query) Count() int {
+	return len(q.Events())
+}
+
+// Earliest returns a copy of the oldest event selected by a query, or nil.
+func (q Query) Earliest() *Event {
+	events := q.Events()
+	if len(events) == 0 {
+		return nil
+	}
+	return events[len(events)-1]
+}
+
+// Latest returns a copy of the newest event selected by a query, or nil.
+func (q Query) Latest() *Event {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	for _, e := range events {
+		if q.expired(e) {
+			return nil
+		}
+
+		if q.Match(e) {
+			return e.copy()
+		}
+	}
+
+	return nil
+}
+
+// Match returns true if a query selects an event, ignoring Since and From.
+func (q Query) Match(e *Event) bool {
+	switch {
+	case len(q.Types) > 0 && !q.Types.Has(e.EventType),
+		q.Team != "" && e.Payload.Team != q.Team,
+		q.Objective != "" && e.Payload.Objective != q.Objective,
+		q.Detector != "" && e.Payload.Detector != q.Detector,
+		q.Unvetoed && e.Vetoed,
+		q.Verified && !e.Verified:
+		return false
+	}
+	return true
+}
+
+// expired returns true if an event was added before the period selected by a query.
+func (q Query) expired(e *Event) bool {
+	return (q.Since > 0 && time.Since(e.Time) > q.Since) || (!q.From.IsZero() && e.Time.Before(q.From))
+}
+
+// Has returns true if t is one of the event types.
+func (types Types) Has(t EventType) bool {
+	for _, e := range types {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package state
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+// Held is an event waiting for review, with the capture it was detected in.
+type Held struct {
+	*Event
+	image.Image
+}
+
+var review = struct {
+	held []held
+
+	mutex *sync.Mutex
+}{
+	mutex: &sync.Mutex{},
+}
+
+type held struct {
+	id int
+	image.Image
+}
+
+// credited are the event types whose points count towards the score of their team.
+var credited = Types{
+	PurpleScore,
+	OrangeScore,
+	FirstScored,
+	PostScore,
+	ScoreAdjustedPurple,
+	ScoreAdjustedOrange,
+	ScoreAdjustedSelf,
+	ScoreOverride,
+	PurpleScoreMissed,
+	OrangeScoreMissed,
+}
+
+// Hold adds an event that does not count towards scores until it is verified, and queues it for review
+// with the capture it was detected in.
+func Hold(e EventType, clock string, points int, p Payload, img image.Image) *Event {
+	event := &Event{
+		EventType: e,
+		Time:      time.Now(),
+		Clock:     clock,
+		Value:     points,
+		Held:      true,
+		Payload:   p.derive(e, points),
+	}
+
+	c := add(event)
+
+	review.mutex.Lock()
+	review.held = append(review.held, held{id: c.id, Image: img})
+	review.mutex.Unlock()
+
+	return c
+}
+
+// Reviews returns a copy of the events waiting for review, oldest first.
+func Reviews() []Held {
+	review.mutex.Lock()
+	defer review.mutex.Unlock()
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	waiting := []Held{}
+	for _, h := range review.held {
+		e := find(h.id)
+		if e != nil && !e.Verified && !e.Vetoed {
+			waiting = append(waiting, Held{Event: e.copy(), Image: h.Image})
+		}
+	}
+
+	return waiting
+}
+
+// Find returns a copy of the event of the current match with an ID, or nil.
+func Find(id int) *Event {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	e := find(id)
+	if e == nil {
+		return nil
+	}
+	return e.copy()
+}
+
+// ID returns the identifier of an event within its match.
+func (e *Event) ID() int {
+	return e.id
+}
+
+// Correct replaces the points credited by an event.
+func (e *Event) Correct(points int) {
+	update(e, func(e *Event) { e.Payload.Points = points })
+}
+
+// Credited returns true if the points of an event count towards the score of its team. Missed scores
+// only count once they are verified.
+func (e *Event) Credited() bool {
+	switch {
+	case !credited.Has(e.EventType), e.Vetoed:
+		return false
+	case e.EventType == PurpleScoreMissed, e.EventType == OrangeScoreMissed, e.Held:
+		return e.Verified
+	default:
+		return true
+	}
+}
+
+// dismiss empties the review queue.
+func dismiss() {
+	review.mutex.Lock()
+	defer review.mutex.Unlock()
+
+	review.held = nil
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/pidgy/unitehud/team"
@@ -15,6 +16,12 @@ type Event struct {
 	Vetoed bool
 
 	Verified bool
+
+	Held bool // Held events count towards scores once they are verified.
+
+	Payload Payload
+
+	id int // Journal entry of the event.
 }
 
 type EventType int
@@ -60,12 +67,28 @@ const (
 	KOStreakOrange         = EventType(35)
 	RayquazaSecureOrange   = EventType(36)
 	RayquazaSecurePurple   = EventType(37)
+	ScoreAdjustedPurple    = EventType(38)
+	ScoreAdjustedOrange    = EventType(39)
+	ScoreAdjustedSelf      = EventType(40)
+	ObjectiveCleared       = EventType(41)
+	ClockAdjusted          = EventType(42)
+	MatchCleared           = EventType(43)
 )
 
 var (
-	Events = []*Event{}
+	events = []*Event{}
+	next   = 0 // ID of the last event added.
+	mutex  = &sync.RWMutex{}
 )
 
+// Events returns a copy of every event, ordered from newest to oldest.
+func Events() []*Event {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return copies(events)
+}
+
 func (e EventType) Int() int {
 	return int(e)
 }
@@ -150,34 +173,105 @@ func (e EventType) String() string {
 		return "Rayquaza secured by Purple team"
 	case RayquazaSecureOrange:
 		return "Rayquaza secured by Orange team"
+	case ScoreAdjustedPurple:
+		return "Purple score adjusted"
+	case ScoreAdjustedOrange:
+		return "Orange score adjusted"
+	case ScoreAdjustedSelf:
+		return "Self score adjusted"
+	case ObjectiveCleared:
+		return "Objective cleared"
+	case ClockAdjusted:
+		return "Clock adjusted"
+	case MatchCleared:
+		return "Match cleared"
 	default:
 		return fmt.Sprintf("Unknown (%d)", e.Int())
 	}
 }
 
 func Add(e EventType, clock string, points int) {
-	event := &Event{
+	AddPayload(e, clock, points, Payload{})
+}
+
+// AddPayload adds an event with a payload, deriving the payload fields that are not set from the event.
+func AddPayload(e EventType, clock string, points int, p Payload) {
+	add(&Event{
 		EventType: e,
 		Time:      time.Now(),
 		Clock:     clock,
 		Value:     points,
+		Payload:   p.derive(e, points),
+	})
+}
+
+// Repeat adds a copy of a recorded event at the current time, keeping its payload and review status.
+func Repeat(e *Event, clock string) {
+	add(&Event{
+		EventType: e.EventType,
+		Time:      time.Now(),
+		Clock:     clock,
+		Value:     e.Value,
+		Vetoed:    e.Vetoed,
+		Verified:  e.Verified,
+		Held:      e.Held,
+		Payload:   e.Payload,
+	})
+}
+
+// add assigns an event the next ID of the match, and adds it to the event log, journal and subscribers.
+func add(event *Event) *Event {
+	mutex.Lock()
+	next++
+	event.id = next
+	events = append([]*Event{event}, events...)
+	c := event.copy()
+	mutex.Unlock()
+
+	record(c)
+	publish(c)
+
+	return c
+}
+
+// update applies a change to an event and its copy in the event log, and journals the change.
+func update(e *Event, change func(e *Event)) {
+	change(e)
+
+	mutex.Lock()
+	stored := find(e.id)
+	if stored == nil {
+		mutex.Unlock()
+		return
 	}
+	if stored != e {
+		change(stored)
+	}
+	c := stored.copy()
+	mutex.Unlock()
 
-	Events = append([]*Event{event}, Events...)
+	record(c)
 }
 
 func Clear() {
-	Events = []*Event{}
+	mutex.Lock()
+	events = []*Event{}
+	next = 0
+	mutex.Unlock()
+
+	rotate()
+	dismiss()
 }
 
 func Dump() (string, bool) {
-	if len(Events) == 0 {
+	events := Events()
+	if len(events) == 0 {
 		return "No event data is available to display...", false
 	}
 
 	str := "Event History"
-	for i := len(Events) - 1; i >= 0; i-- {
-		e := Events[i]
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
 
 		str = fmt.Sprintf("%s\n%s", str, e.String())
 		if e.Value != -1 {
@@ -186,6 +280,9 @@ func Dump() (string, bool) {
 		if e.Vetoed {
 			str += " (Vetoed)"
 		}
+		if e.Held && !e.Verified && !e.Vetoed {
+			str += " (Held)"
+		}
 		if e.Verified {
 			str += " (Verified)"
 		}
@@ -209,38 +306,50 @@ func (e *Event) String() string {
 	return fmt.Sprintf("[%02d:%02d:%02d] [Event] [%s] %s", e.Time.Hour(), e.Time.Minute(), e.Time.Second(), e.Clock, e.EventType)
 }
 
-func (e *Event) Strip() string {
-	return fmt.Sprintf("[%s] %s", e.Clock, e.EventType)
+// copy returns a copy of an event that can be read while the original is updated.
+func (e *Event) copy() *Event {
+	c := *e
+	return &c
 }
 
-func (e EventType) Occured(since time.Duration) *Event {
-	for _, event := range Events {
-		// Have we gone too far?
-		if time.Since(event.Time) > since {
-			return nil
-		}
+func copies(events []*Event) []*Event {
+	c := make([]*Event, len(events))
+	for i, e := range events {
+		c[i] = e.copy()
+	}
+	return c
+}
 
-		if event.EventType == e {
-			return event
+// find returns the event of the event log with an ID, the caller must hold the mutex.
+func find(id int) *Event {
+	for _, e := range events {
+		if e.id == id {
+			return e
 		}
 	}
-
 	return nil
 }
 
+func (e *Event) Strip() string {
+	return fmt.Sprintf("[%s] %s", e.Clock, e.EventType)
+}
+
+func (e EventType) Occured(since time.Duration) *Event {
+	return Query{Types: Types{e}, Since: since}.Latest()
+}
+
 func Start() *Event {
-	if len(Events) == 0 {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if len(events) == 0 {
 		return &Event{}
 	}
-	return Events[0]
+	return events[0].copy()
 }
 
 func First(e EventType, since time.Duration) *Event {
-	events := Past(e, since)
-	if len(events) > 0 {
-		return events[len(events)-1]
-	}
-	return nil
+	return Query{Types: Types{e}, Since: since}.Earliest()
 }
 
 func Occured(since time.Duration, e ...EventType) *Event {
@@ -254,46 +363,17 @@ func Occured(since time.Duration, e ...EventType) *Event {
 }
 
 func Past(e EventType, since time.Duration) []*Event {
-	events := []*Event{}
-
-	for _, event := range Events {
-		// Have we gone too far?
-		if time.Since(event.Time) > since {
-			return events
-		}
-
-		if event.EventType == e {
-			if time.Since(event.Time) > since {
-				return events
-			}
-
-			events = append(events, event)
-		}
-	}
-
-	return events
+	return Query{Types: Types{e}, Since: since}.Events()
 }
 
 func Recent(e EventType) bool {
-	for i := len(Events) - 1; i >= 0; i-- {
-		if Events[i].EventType == e {
-			return true
-		}
-	}
-	return false
+	return Query{Types: Types{e}}.Latest() != nil
 }
 
+// Before returns true if this occured, and occured before that.
 func (this EventType) Before(that EventType) bool {
-	for i := len(Events) - 1; i >= 0; i-- {
-		switch {
-		case Events[i].EventType == this:
-			return true
-		case Events[i].EventType == that:
-			return false
-		}
-	}
-
-	return false
+	first := Query{Types: Types{this, that}}.Earliest()
+	return first != nil && first.EventType == this
 }
 
 func ScoredBy(name string) EventType {
@@ -324,18 +404,69 @@ func ScoreMissedBy(name string) EventType {
 	return Nothing
 }
 
+func ScoreAdjustedBy(name string) EventType {
+	switch name {
+	case team.Purple.Name:
+		return ScoreAdjustedPurple
+	case team.Orange.Name:
+		return ScoreAdjustedOrange
+	case team.Self.Name:
+		return ScoreAdjustedSelf
+	}
+	return Nothing
+}
+
+func SecuredBy(objective, name string) EventType {
+	purple := name == team.Purple.Name
+
+	switch objective {
+	case "regieleki":
+		if purple {
+			return RegielekiSecurePurple
+		}
+		return RegielekiSecureOrange
+	case "regice":
+		if purple {
+			return RegiceSecurePurple
+		}
+		return RegiceSecureOrange
+	case "regirock":
+		if purple {
+			return RegirockSecurePurple
+		}
+		return RegirockSecureOrange
+	case "registeel":
+		if purple {
+			return RegisteelSecurePurple
+		}
+		return RegisteelSecureOrange
+	case "rayquaza":
+		if purple {
+			return RayquazaSecurePurple
+		}
+		return RayquazaSecureOrange
+	}
+	return Nothing
+}
+
 func Since() time.Duration {
-	if len(Events) == 0 {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if len(events) == 0 {
 		return 0
 	}
 
-	return time.Since(Events[0].Time)
+	return time.Since(events[0].Time)
 }
 
 func Strings(since time.Duration) []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
 	s := []string{}
 
-	for _, event := range Events {
+	for _, event := range events {
 		if time.Since(event.Time) > since {
 			return s
 		}
@@ -0,0 +1,197 @@
+package state
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pidgy/unitehud/team"
+)
+
+// TestConcurrentDetectors adds events from several detectors while readers query, review and subscribe to
+// them, run with -race.
+func TestConcurrentDetectors(t *testing.T) {
+	Journal = t.TempDir()
+	Clear()
+	defer Clear()
+
+	const (
+		detectors = 4
+		adds      = 200
+	)
+
+	added := Subscribe(Query{Types: Types{PurpleScore}}, 8)
+	defer added.Close()
+
+	wg := &sync.WaitGroup{}
+	done := make(chan bool)
+
+	for d := 0; d < detectors; d++ {
+		wg.Add(1)
+		go func(d int) {
+			defer wg.Done()
+
+			for i := 0; i < adds; i++ {
+				switch i % 3 {
+				case 0:
+					AddPayload(PurpleScore, "05:00", 2, Payload{Detector: "Purple Score"})
+				case 1:
+					Hold(OrangeScore, "05:00", 5, Payload{}, nil)
+				default:
+					Add(KOPurple, "05:00", -1)
+				}
+			}
+		}(d)
+	}
+
+	readers := &sync.WaitGroup{}
+	readers.Add(3)
+
+	go func() {
+		defer readers.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			Strings(time.Minute)
+			Dump()
+			Since()
+			Start()
+			Query{Types: Types{PurpleScore}, Team: team.Purple.Name}.Count()
+			PurpleScore.Before(OrangeScore)
+		}
+	}()
+
+	go func() {
+		defer readers.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			for _, h := range Reviews() {
+				if h.ID()%2 == 0 {
+					h.Correct(3)
+					h.Verify()
+				} else {
+					h.Veto()
+				}
+			}
+
+			e := Find(1)
+			if e != nil {
+				_ = e.Verified
+			}
+		}
+	}()
+
+	go func() {
+		defer readers.Done()
+
+		for {
+			select {
+			case <-done:
+				return
+			case e := <-added.Events:
+				_ = e.Payload.Points
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	n := len(Events())
+	if n != detectors*adds {
+		t.Fatalf("expected %d events, got %d", detectors*adds, n)
+	}
+
+	ids := map[int]bool{}
+	for _, e := range Events() {
+		if ids[e.ID()] {
+			t.Fatalf("event #%d was added twice", e.ID())
+		}
+		ids[e.ID()] = true
+	}
+
+	for _, h := range Reviews() {
+		h.Veto()
+	}
+
+	for _, e := range Events() {
+		switch {
+		case e.EventType == PurpleScore && !e.Credited():
+			t.Fatalf("expected purple score #%d to be credited", e.ID())
+		case e.EventType == OrangeScore && e.Credited() != (e.Verified && !e.Vetoed):
+			t.Fatalf("expected held orange score #%d to be credited only once verified", e.ID())
+		}
+	}
+}
+
+// TestCopies ensures events handed out by queries and subscriptions do not change with the event log.
+func TestCopies(t *testing.T) {
+	Journal = ""
+	Clear()
+	defer Clear()
+
+	s := Subscribe(Query{}, 1)
+	defer s.Close()
+
+	e := Hold(PurpleScoreMissed, "01:00", 0, Payload{}, nil)
+	published := <-s.Events
+	queried := PurpleScoreMissed.Occured(time.Minute)
+
+	e.Correct(7)
+	e.Verify()
+
+	if published.Verified || queried.Verified || queried.Payload.Points != 0 {
+		t.Fatalf("copies changed with the event log")
+	}
+
+	stored := Find(e.ID())
+	if !stored.Verified || stored.Payload.Points != 7 || !stored.Credited() {
+		t.Fatalf("expected event #%d to be verified and credited 7 points, got %+v", e.ID(), stored)
+	}
+
+	if len(Reviews()) != 0 {
+		t.Fatalf("expected no events waiting for review")
+	}
+}
+
+// TestQueryFrom ensures queries from a time ignore earlier events, and that Before requires this to occur.
+func TestQueryFrom(t *testing.T) {
+	Journal = ""
+	Clear()
+	defer Clear()
+
+	if PurpleScore.Before(OrangeScore) {
+		t.Fatalf("expected purple score not to occur before an orange score that never occured")
+	}
+
+	// Clocks can be coarse, keep the events apart.
+	Add(KilledWithPoints, "05:00", -1)
+	time.Sleep(time.Millisecond * 20)
+	from := time.Now()
+	time.Sleep(time.Millisecond * 20)
+	Add(PressButtonToScore, "04:59", -1)
+
+	if (Query{Types: Types{KilledWithPoints}, From: from}).Latest() != nil {
+		t.Fatalf("expected events added before %s to be ignored", from)
+	}
+
+	if (Query{Types: Types{PressButtonToScore}, From: from}).Latest() == nil {
+		t.Fatalf("expected events added after %s to be selected", from)
+	}
+
+	if !KilledWithPoints.Before(PressButtonToScore) {
+		t.Fatalf("expected killed with points to occur before the score option")
+	}
+}
@@ -3,6 +3,7 @@ package device
 import (
 	"fmt"
 	"image"
+	"strings"
 	"time"
 
 	"gocv.io/x/gocv"
@@ -11,7 +12,6 @@ import (
 	"github.com/pidgy/unitehud/img"
 	"github.com/pidgy/unitehud/notify"
 	"github.com/pidgy/unitehud/splash"
-	"github.com/pidgy/unitehud/video/device/win32"
 	"github.com/pidgy/unitehud/video/monitor"
 )
 
@@ -52,10 +52,7 @@ func ActiveName() string {
 	if active == config.NoVideoCaptureDevice {
 		return "Disabled"
 	}
-	if len(names) > active {
-		return names[active]
-	}
-	return fmt.Sprintf("Video Capture Device: %d", active)
+	return Name(active)
 }
 
 func Capture() (*image.RGBA, error) {
@@ -117,13 +114,36 @@ func Open() error {
 	return nil
 }
 
+// Modes returns the frame sizes supported by a video capture device, when they can be discovered.
+func Modes(d int) []image.Point {
+	return modes(d)
+}
+
 func Name(d int) string {
-	if d != config.NoVideoCaptureDevice && len(names) > d {
-		return names[d]
+	for i := range Sources {
+		if Sources[i] == d && d != config.NoVideoCaptureDevice && len(names) > i {
+			return names[i]
+		}
 	}
 	return fmt.Sprintf("Video Capture Device: %d", d)
 }
 
+// mode returns the configured frame size of the video capture device, defaulting to the main resolution.
+func mode() (image.Point, error) {
+	if config.Current.VideoCaptureMode == "" {
+		return monitor.MainResolution().Max, nil
+	}
+
+	p := image.Point{}
+
+	_, err := fmt.Sscanf(config.Current.VideoCaptureMode, "%dx%d", &p.X, &p.Y)
+	if err != nil || p.X <= 0 || p.Y <= 0 {
+		return p, fmt.Errorf("invalid video capture mode \"%s\", expected WIDTHxHEIGHT", config.Current.VideoCaptureMode)
+	}
+
+	return p, nil
+}
+
 func reset() {
 	config.Current.Window = config.MainDisplay
 	config.Current.VideoCaptureDevice = config.NoVideoCaptureDevice
@@ -133,23 +153,6 @@ func resetActive() {
 	active = config.NoVideoCaptureDevice
 }
 
-func sources() ([]int, []string) {
-	s := []int{}
-	n := []string{}
-
-	for i := 0; i < 10; i++ {
-		name := win32.VideoCaptureDeviceName(i)
-		if name == "" {
-			break
-		}
-
-		s = append(s, i)
-		n = append(n, name)
-	}
-
-	return s, n
-}
-
 func startCaptureDevice() error {
 	errq := make(chan error)
 
@@ -165,20 +168,34 @@ func startCaptureDevice() error {
 		name := Name(config.Current.VideoCaptureDevice)
 
 		notify.System("Starting video capture (%s)", name)
+
+		m := []string{}
+		for _, p := range Modes(config.Current.VideoCaptureDevice) {
+			m = append(m, fmt.Sprintf("%dx%d", p.X, p.Y))
+		}
+		if len(m) > 0 {
+			notify.System("%s supports %s", name, strings.Join(m, ", "))
+		}
 		defer notify.System("Closing video capture (%s)", name)
 
-		device, err := gocv.OpenVideoCaptureWithAPI(config.Current.VideoCaptureDevice, gocv.VideoCaptureAny)
+		device, err := gocv.OpenVideoCaptureWithAPI(config.Current.VideoCaptureDevice, api)
 		if err != nil {
 			errq <- err
 			return
 		}
 		defer device.Close()
 
-		device.Set(gocv.VideoCaptureFrameWidth, float64(monitor.MainResolution().Dx()))
-		device.Set(gocv.VideoCaptureFrameHeight, float64(monitor.MainResolution().Dy()))
+		size, err := mode()
+		if err != nil {
+			errq <- err
+			return
+		}
+
+		device.Set(gocv.VideoCaptureFrameWidth, float64(size.X))
+		device.Set(gocv.VideoCaptureFrameHeight, float64(size.Y))
 
 		area := image.Rect(0, 0, int(device.Get(gocv.VideoCaptureFrameWidth)), int(device.Get(gocv.VideoCaptureFrameHeight)))
-		if !area.Eq(monitor.MainResolution()) {
+		if !area.Max.Eq(size) {
 			mat = splash.DeviceMat().Clone()
 			errq <- fmt.Errorf("%s has invalid dimensions: %s", name, area.String())
 			return
@@ -186,6 +203,12 @@ func startCaptureDevice() error {
 
 		close(errq)
 
+		// Frames captured in a mode other than the main resolution are scaled to fit.
+		scaled := !area.Eq(monitor.MainResolution())
+
+		frame := gocv.NewMat()
+		defer frame.Close()
+
 		for running && active == config.Current.VideoCaptureDevice {
 			time.Sleep(time.Millisecond)
 
@@ -197,10 +220,19 @@ func startCaptureDevice() error {
 				continue
 			}
 
-			if !device.Read(&mat) || mat.Empty() {
+			if !scaled {
+				if !device.Read(&mat) || mat.Empty() {
+					notify.Warn("Failed to read from %s", name)
+				}
+				continue
+			}
+
+			if !device.Read(&frame) || frame.Empty() {
 				notify.Warn("Failed to read from %s", name)
 				continue
 			}
+
+			gocv.Resize(frame, &mat, monitor.MainResolution().Max, 0, 0, gocv.InterpolationLinear)
 		}
 	}()
 
@@ -0,0 +1,32 @@
+package device
+
+import (
+	"image"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/video/device/v4l2"
+)
+
+const api = gocv.VideoCaptureV4L2
+
+func modes(d int) []image.Point {
+	for _, dev := range v4l2.Devices() {
+		if dev.Index == d {
+			return dev.Modes
+		}
+	}
+	return nil
+}
+
+func sources() ([]int, []string) {
+	s := []int{}
+	n := []string{}
+
+	for _, dev := range v4l2.Devices() {
+		s = append(s, dev.Index)
+		n = append(n, dev.Name)
+	}
+
+	return s, n
+}
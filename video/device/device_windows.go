@@ -0,0 +1,33 @@
+package device
+
+import (
+	"image"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/video/device/win32"
+)
+
+const api = gocv.VideoCaptureAny
+
+// modes is unsupported by DirectShow device names, devices are opened using the configured mode.
+func modes(d int) []image.Point {
+	return nil
+}
+
+func sources() ([]int, []string) {
+	s := []int{}
+	n := []string{}
+
+	for i := 0; i < 10; i++ {
+		name := win32.VideoCaptureDeviceName(i)
+		if name == "" {
+			break
+		}
+
+		s = append(s, i)
+		n = append(n, name)
+	}
+
+	return s, n
+}
@@ -0,0 +1,179 @@
+//go:build linux
+// +build linux
+
+// Package v4l2 discovers Video4Linux capture devices and the frame sizes they support.
+package v4l2
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+type Device struct {
+	Index int // Index used by OpenCV, /dev/video{Index}.
+	Path  string
+	Name  string
+	Modes []image.Point // Supported frame sizes, largest first.
+}
+
+// https://www.kernel.org/doc/html/latest/userspace-api/media/v4l/videodev.html
+const (
+	vidiocQueryCap        = 0x80685600 // _IOR('V', 0, struct v4l2_capability)
+	vidiocEnumFmt         = 0xc0405602 // _IOWR('V', 2, struct v4l2_fmtdesc)
+	vidiocEnumFrameSizes  = 0xc02c564a // _IOWR('V', 74, struct v4l2_frmsizeenum)
+	capVideoCapture       = 0x00000001
+	capDeviceCaps         = 0x80000000
+	bufTypeVideoCapture   = 1
+	frameSizeTypeDiscrete = 1
+)
+
+type capability struct {
+	driver       [16]byte
+	card         [32]byte
+	busInfo      [32]byte
+	version      uint32
+	capabilities uint32
+	deviceCaps   uint32
+	reserved     [3]uint32
+}
+
+type fmtdesc struct {
+	index       uint32
+	typ         uint32
+	flags       uint32
+	description [32]byte
+	pixelformat uint32
+	mbusCode    uint32
+	reserved    [3]uint32
+}
+
+type frmsizeenum struct {
+	index       uint32
+	pixelFormat uint32
+	typ         uint32
+	// Discrete width and height, or the stepwise min width, max width, step width, min height,
+	// max height and step height.
+	size     [6]uint32
+	reserved [2]uint32
+}
+
+// Devices returns every /dev/video* device capable of capturing video, ordered by index. Metadata
+// nodes created by most capture cards are ignored.
+func Devices() []Device {
+	paths, err := filepath.Glob("/dev/video*")
+	if err != nil {
+		return nil
+	}
+
+	devices := []Device{}
+
+	for _, p := range paths {
+		index, err := strconv.Atoi(strings.TrimPrefix(p, "/dev/video"))
+		if err != nil {
+			continue
+		}
+
+		d, err := open(p)
+		if err != nil {
+			continue
+		}
+
+		d.Index = index
+
+		devices = append(devices, d)
+	}
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Index < devices[j].Index })
+
+	return devices
+}
+
+func open(path string) (Device, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return Device{}, err
+	}
+	defer f.Close()
+
+	fd := f.Fd()
+
+	c := capability{}
+	err = ioctl(fd, vidiocQueryCap, unsafe.Pointer(&c))
+	if err != nil {
+		return Device{}, err
+	}
+
+	caps := c.capabilities
+	if caps&capDeviceCaps != 0 {
+		caps = c.deviceCaps
+	}
+
+	if caps&capVideoCapture == 0 {
+		return Device{}, fmt.Errorf("%s is not a video capture device", path)
+	}
+
+	return Device{
+		Path:  path,
+		Name:  string(bytes.TrimRight(c.card[:], "\x00")),
+		Modes: modes(fd),
+	}, nil
+}
+
+// modes returns the discrete frame sizes of every pixel format, or the maximum frame size of stepwise
+// and continuous formats.
+func modes(fd uintptr) []image.Point {
+	found := map[image.Point]bool{}
+
+	for i := uint32(0); ; i++ {
+		f := fmtdesc{index: i, typ: bufTypeVideoCapture}
+		if ioctl(fd, vidiocEnumFmt, unsafe.Pointer(&f)) != nil {
+			break
+		}
+
+		for j := uint32(0); ; j++ {
+			s := frmsizeenum{index: j, pixelFormat: f.pixelformat}
+			if ioctl(fd, vidiocEnumFrameSizes, unsafe.Pointer(&s)) != nil {
+				break
+			}
+
+			if s.typ == frameSizeTypeDiscrete {
+				found[image.Pt(int(s.size[0]), int(s.size[1]))] = true
+				continue
+			}
+
+			found[image.Pt(int(s.size[1]), int(s.size[4]))] = true
+			break
+		}
+	}
+
+	m := []image.Point{}
+	for p := range found {
+		m = append(m, p)
+	}
+
+	sort.Slice(m, func(i, j int) bool {
+		if m[i].X == m[j].X {
+			return m[i].Y > m[j].Y
+		}
+		return m[i].X > m[j].X
+	})
+
+	return m
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package file
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gocv.io/x/gocv"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/img"
+	"github.com/pidgy/unitehud/notify"
+)
+
+// framerate is the playback rate of a directory of PNG frames when playing in real time.
+const framerate = 30
+
+var (
+	active = ""
+	mat    = gocv.NewMat()
+	mutex  = &sync.Mutex{}
+
+	running = false
+	stopped = true
+
+	requested = false
+)
+
+// source reads the frames of a video file or a directory of PNG images.
+type source interface {
+	fps() float64
+	read(m *gocv.Mat) bool
+	close()
+}
+
+// Capture returns the current frame at its own size.
+func Capture() (*image.RGBA, error) {
+	return capture(nil)
+}
+
+func CaptureRect(rect image.Rectangle) (*image.RGBA, error) {
+	return capture(&rect)
+}
+
+func capture(rect *image.Rectangle) (*image.RGBA, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	requested = true
+
+	if mat.Empty() {
+		return nil, fmt.Errorf("no video file frame is available")
+	}
+
+	bounds := image.Rect(0, 0, mat.Cols(), mat.Rows())
+	if rect == nil {
+		return img.RGBA(mat.Region(bounds))
+	}
+
+	if !rect.In(bounds) {
+		return nil, fmt.Errorf("capture is outside of the legal boundary (%s intersects %s)", rect, bounds)
+	}
+
+	return img.RGBA(mat.Region(*rect))
+}
+
+func Close() {
+	defer func() { active = "" }()
+
+	if !running {
+		notify.Debug("Ignorning call to close video file (%s)", ActiveName())
+		return
+	}
+
+	running = false
+	for !stopped {
+		time.Sleep(time.Microsecond)
+	}
+}
+
+func ActiveName() string {
+	if active == "" {
+		return "Disabled"
+	}
+	return filepath.Base(active)
+}
+
+// IsActive returns true when a video file is configured, taking priority over every other capture source.
+func IsActive() bool {
+	return config.Current.VideoFile != "" && config.Current.VideoFile == active
+}
+
+func Open() error {
+	if running && active != config.Current.VideoFile {
+		Close()
+	}
+
+	if running || config.Current.VideoFile == "" {
+		notify.Debug("Ignorning call to open video file (%s)", ActiveName())
+		return nil
+	}
+
+	active = config.Current.VideoFile
+
+	err := startVideoFile()
+	if err != nil {
+		active = ""
+		return err
+	}
+
+	return nil
+}
+
+func startVideoFile() error {
+	s, err := open(config.Current.VideoFile)
+	if err != nil {
+		return err
+	}
+
+	name := ActiveName()
+
+	// Seed the first frame so captures never race the playback goroutine.
+	mutex.Lock()
+	ok := s.read(&mat) && !mat.Empty()
+	mutex.Unlock()
+	if !ok {
+		s.close()
+		return fmt.Errorf("failed to read the first frame of %s", name)
+	}
+
+	running = true
+	stopped = false
+
+	// The last frame remains available for capture once playback has finished.
+	go func() {
+		defer func() {
+			running = false
+			stopped = true
+		}()
+
+		notify.System("Starting video file playback (%s)", name)
+		defer notify.System("Closing video file playback (%s)", name)
+
+		defer s.close()
+
+		frame := gocv.NewMat()
+		defer frame.Close()
+
+		delay := time.Duration(0)
+		if config.Current.VideoFileRealTime && s.fps() > 0 {
+			delay = time.Duration(float64(time.Second) / s.fps())
+		}
+
+		frames := 1
+		start := time.Now()
+
+		for running && active == config.Current.VideoFile {
+			if delay > 0 {
+				// Skip frames when detection falls behind the playback clock.
+				next := start.Add(delay * time.Duration(frames))
+				if time.Now().Before(next) {
+					time.Sleep(time.Until(next))
+				}
+			} else {
+				// As fast as possible, but never faster than frames are being captured.
+				time.Sleep(time.Millisecond)
+
+				mutex.Lock()
+				ok := requested
+				mutex.Unlock()
+
+				if !ok {
+					continue
+				}
+			}
+
+			if !s.read(&frame) || frame.Empty() {
+				notify.System("Finished video file playback (%s, %d frames in %s)", name, frames, time.Since(start).Round(time.Second))
+				return
+			}
+			frames++
+
+			mutex.Lock()
+			frame.CopyTo(&mat)
+			requested = false
+			mutex.Unlock()
+		}
+	}()
+
+	return nil
+}
+
+func open(path string) (source, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return openFrames(path)
+	}
+
+	v, err := gocv.VideoCaptureFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.IsOpened() {
+		v.Close()
+		return nil, fmt.Errorf("failed to open %s", path)
+	}
+
+	return &video{v}, nil
+}
+
+type video struct {
+	*gocv.VideoCapture
+}
+
+func (v *video) fps() float64 {
+	return v.Get(gocv.VideoCaptureFPS)
+}
+
+func (v *video) read(m *gocv.Mat) bool {
+	return v.Read(m)
+}
+
+func (v *video) close() {
+	v.Close()
+}
+
+// frames reads a directory of PNG images in lexical order.
+type frames struct {
+	files []string
+	next  int
+}
+
+func openFrames(dir string) (*frames, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	f := &frames{}
+	for _, file := range files {
+		if strings.EqualFold(filepath.Ext(file), ".png") {
+			f.files = append(f.files, file)
+		}
+	}
+
+	if len(f.files) == 0 {
+		return nil, fmt.Errorf("%s does not contain any PNG frames", dir)
+	}
+
+	sort.Strings(f.files)
+
+	return f, nil
+}
+
+func (f *frames) fps() float64 {
+	return framerate
+}
+
+func (f *frames) read(m *gocv.Mat) bool {
+	if f.next >= len(f.files) {
+		return false
+	}
+
+	i := gocv.IMRead(f.files[f.next], gocv.IMReadColor)
+	defer i.Close()
+
+	f.next++
+
+	if i.Empty() {
+		notify.Warn("Failed to read frame %s", f.files[f.next-1])
+		return true
+	}
+
+	i.CopyTo(m)
+
+	return true
+}
+
+func (f *frames) close() {}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/kbinani/screenshot"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/notify"
+)
+
+var mainDisplay = image.Rectangle{}
+
+// CaptureRect captures an area of the selected display from the X11 root window, using the MIT-SHM
+// extension when it is available and XGetImage otherwise. Scaling is not supported by X11 displays.
+func CaptureRect(rect image.Rectangle) (*image.RGBA, error) {
+	// X11 display bounds are absolute, like the areas captured from the root window.
+	rect = rect.Add(dims().Min)
+
+	img, err := screenshot.Capture(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+	if err != nil {
+		notify.Error("Failed to capture \"%s\"", config.Current.Window)
+		return nil, err
+	}
+
+	return img, nil
+}
+
+func MainResolution() image.Rectangle {
+	if mainResolution.Max.Eq(image.Pt(0, 0)) {
+		m, err := mainDisplayRect()
+		if err != nil {
+			notify.Error("Failed to find main display resolution (%v)", err)
+			return mainResolution
+		}
+
+		mainResolution = image.Rectangle{Max: m.Size()}
+	}
+
+	return mainResolution
+}
+
+// mainDisplayRect returns the bounds of the first X11 display, which are cached to avoid opening a
+// connection to the X server for every capture.
+func mainDisplayRect() (image.Rectangle, error) {
+	if !mainDisplay.Empty() {
+		return mainDisplay, nil
+	}
+
+	if screenshot.NumActiveDisplays() == 0 {
+		return image.Rectangle{}, fmt.Errorf("no active X11 displays")
+	}
+
+	mainDisplay = screenshot.GetDisplayBounds(0)
+
+	return mainDisplay, nil
+}
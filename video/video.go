@@ -5,11 +5,16 @@ import (
 
 	"github.com/pidgy/unitehud/notify"
 	"github.com/pidgy/unitehud/video/device"
+	"github.com/pidgy/unitehud/video/file"
 	"github.com/pidgy/unitehud/video/monitor"
 	"github.com/pidgy/unitehud/video/window"
 )
 
 func Capture() (img *image.RGBA, err error) {
+	if file.IsActive() {
+		return file.Capture()
+	}
+
 	if device.IsActive() {
 		return device.Capture()
 	}
@@ -22,6 +27,10 @@ func Capture() (img *image.RGBA, err error) {
 }
 
 func CaptureRect(rect image.Rectangle) (img *image.RGBA, err error) {
+	if file.IsActive() {
+		return file.CaptureRect(rect)
+	}
+
 	if device.IsActive() {
 		return device.CaptureRect(rect)
 	}
@@ -35,12 +44,18 @@ func CaptureRect(rect image.Rectangle) (img *image.RGBA, err error) {
 
 func Close() {
 	device.Close()
+	file.Close()
 }
 
 func Open() error {
 	monitor.Open()
 
-	err := device.Open()
+	err := file.Open()
+	if err != nil {
+		return err
+	}
+
+	err = device.Open()
 	if err != nil {
 		return err
 	}
@@ -60,6 +75,18 @@ func Screens() []string {
 	return monitor.Sources
 }
 
+// DefeatedArea returns the area of a capture the defeated screen is shown in.
+func DefeatedArea() image.Rectangle {
+	i, err := Capture()
+	if err != nil {
+		notify.Error("Failed to capture area for defeated events (%v)", err)
+		return image.Rect(0, 0, 0, 0)
+	}
+
+	b := i.Bounds()
+	return image.Rect(b.Max.X/3, b.Max.Y/2, b.Max.X-b.Max.X/3, b.Max.Y-b.Max.Y/3)
+}
+
 func StateArea() image.Rectangle {
 	i, err := Capture()
 	if err != nil {
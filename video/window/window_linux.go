@@ -0,0 +1,372 @@
+//go:build linux
+// +build linux
+
+package window
+
+import (
+	"fmt"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+
+	"github.com/pidgy/unitehud/config"
+	"github.com/pidgy/unitehud/notify"
+	"github.com/pidgy/unitehud/video/monitor"
+)
+
+var (
+	Sources = []string{}
+
+	lock = &sync.Mutex{}
+
+	conn *xgb.Conn
+	root xproto.Window
+
+	// found caches the handle of the selected window, so captures do not list every window.
+	found = struct {
+		name   string
+		handle xproto.Window
+		*sync.Mutex
+	}{Mutex: &sync.Mutex{}}
+)
+
+func init() {
+	go func() {
+		failed := false
+		wait := time.Second * 5
+
+		for {
+			time.Sleep(wait)
+
+			windows, handles, err := list()
+			if err != nil {
+				// Without an X server every attempt fails the same way, log once and back off.
+				if !failed {
+					notify.Error("Failed to list windows (%v)", err)
+				}
+				failed = true
+
+				if wait < time.Minute {
+					wait *= 2
+				}
+
+				continue
+			}
+
+			if failed {
+				notify.System("Listing windows")
+			}
+			failed = false
+			wait = time.Second * 5
+
+			Sources = windows
+
+			cache(windows, handles)
+		}
+	}()
+}
+
+// Capture captures the desired area from a Window and returns an image.
+func Capture() (*image.RGBA, error) {
+	handle, err := find(config.Current.Window)
+	if err != nil {
+		notify.Error("Failed to find %s (%v)", config.Current.Window, err)
+		return monitor.Capture()
+	}
+
+	rect, err := windowRect(handle)
+	if err != nil {
+		notify.Error("Failed to find window dimensions \"%s\" (%v)", config.Current.Window, err)
+		forget()
+		lose()
+		return monitor.Capture()
+	}
+
+	img, err := CaptureRect(rect)
+	if err != nil {
+		notify.Error("Failed to capture \"%s\" window (%v)", config.Current.Window, err)
+		forget()
+		lose()
+		return monitor.Capture()
+	}
+
+	return img, err
+}
+
+// CaptureRect captures an area of a window using XGetImage. Areas of the window covered by other
+// windows are only captured correctly when a compositing window manager is running.
+func CaptureRect(rect image.Rectangle) (*image.RGBA, error) {
+	handle, err := find(config.Current.Window)
+	if err != nil {
+		notify.Error("%v", err)
+		return monitor.CaptureRect(rect)
+	}
+
+	c, _, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := xproto.GetImage(
+		c,
+		xproto.ImageFormatZPixmap,
+		xproto.Drawable(handle),
+		int16(rect.Min.X),
+		int16(rect.Min.Y),
+		uint16(rect.Dx()),
+		uint16(rect.Dy()),
+		0xffffffff,
+	).Reply()
+	if err != nil {
+		forget()
+		return nil, fmt.Errorf("failed to capture \"%s\" window (%v)", config.Current.Window, err)
+	}
+
+	width, height := rect.Dx(), rect.Dy()
+
+	if len(reply.Data) < width*height*4 {
+		return nil, fmt.Errorf("unsupported \"%s\" window depth (%d)", config.Current.Window, reply.Depth)
+	}
+
+	// ZPixmap data is BGRX for 24 and 32 bit depths.
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = reply.Data[i+2], reply.Data[i+1], reply.Data[i], 255
+	}
+
+	return img, nil
+}
+
+func IsOpen() bool {
+	return !Lost()
+}
+
+func Open() error {
+	windows, _, err := list()
+	if err != nil {
+		return err
+	}
+
+	Sources = windows
+
+	for _, win := range windows {
+		if win == config.Current.Window {
+			if !monitor.IsDisplay() {
+				config.Current.LostWindow = ""
+			}
+			return nil
+		}
+	}
+
+	if monitor.IsDisplay() {
+		return nil
+	}
+
+	notify.Error("\"%s\" could not be found", config.Current.Window)
+
+	lose()
+
+	return nil
+}
+
+func Lost() bool {
+	return config.Current.LostWindow != ""
+}
+
+var attempts = 0
+
+func Reattach() error {
+	if !Lost() {
+		return nil
+	}
+
+	max := 5
+	windows, _, err := list()
+	if err != nil {
+		return err
+	}
+
+	for _, win := range windows {
+		if win == config.Current.LostWindow {
+			config.Current.Window = win
+
+			notify.Announce("Found \"%s\" window", config.Current.Window)
+			config.Current.LostWindow = ""
+			attempts = 0
+
+			return nil
+		}
+	}
+
+	attempts++
+	if attempts == max {
+		config.Current.Window = config.MainDisplay
+		config.Current.LostWindow = ""
+		attempts = 0
+	}
+
+	return nil
+}
+
+// atom returns the X11 atom of a property name.
+func atom(c *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(c, true, uint16(len(name)), name).Reply()
+	if err != nil {
+		return xproto.AtomNone, err
+	}
+	return reply.Atom, nil
+}
+
+// connect returns the shared connection to the X server, and the root window of the default screen.
+func connect() (*xgb.Conn, xproto.Window, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if conn != nil {
+		return conn, root, nil
+	}
+
+	c, err := xgb.NewConn()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conn = c
+	root = xproto.Setup(c).DefaultScreen(c).Root
+
+	return conn, root, nil
+}
+
+// cache updates the handle of the selected window from a list of windows, forgetting it when the window
+// was closed or renamed.
+func cache(windows []string, handles []xproto.Window) {
+	found.Lock()
+	defer found.Unlock()
+
+	for i := range windows {
+		if windows[i] == found.name {
+			found.handle = handles[i]
+			return
+		}
+	}
+
+	found.name, found.handle = "", 0
+}
+
+// find finds the handle to the window, listing windows only when it is not cached.
+func find(name string) (xproto.Window, error) {
+	found.Lock()
+	if found.name == name && found.handle != 0 {
+		defer found.Unlock()
+		return found.handle, nil
+	}
+	found.Unlock()
+
+	windows, handles, err := list()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range windows {
+		if windows[i] == name {
+			found.Lock()
+			found.name, found.handle = name, handles[i]
+			found.Unlock()
+
+			return handles[i], nil
+		}
+	}
+
+	lose()
+
+	return 0, fmt.Errorf("Failed to find \"%s\"", name)
+}
+
+// list returns the title of every window managed by the window manager, using _NET_CLIENT_LIST.
+func list() ([]string, []xproto.Window, error) {
+	c, r, err := connect()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clients, err := atom(c, "_NET_CLIENT_LIST")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reply, err := xproto.GetProperty(c, false, r, clients, xproto.AtomWindow, 0, 1<<16).Reply()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := []string{}
+	windows := []xproto.Window{}
+
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		w := xproto.Window(xgb.Get32(reply.Value[i:]))
+
+		name, err := title(c, w)
+		if err != nil || name == "" || name == config.ProjectorWindow {
+			continue
+		}
+
+		names = append(names, name)
+		windows = append(windows, w)
+	}
+
+	return names, windows, nil
+}
+
+// forget clears the cached handle of the selected window.
+func forget() {
+	found.Lock()
+	defer found.Unlock()
+
+	found.name, found.handle = "", 0
+}
+
+func lose() {
+	if config.Current.LostWindow == "" {
+		config.Current.LostWindow = config.Current.Window
+	}
+	config.Current.Window = config.MainDisplay
+}
+
+// title returns the _NET_WM_NAME of a window, or WM_NAME for windows without one.
+func title(c *xgb.Conn, w xproto.Window) (string, error) {
+	name, err := atom(c, "_NET_WM_NAME")
+	if err != nil {
+		return "", err
+	}
+
+	for _, a := range []xproto.Atom{name, xproto.AtomWmName} {
+		reply, err := xproto.GetProperty(c, false, w, a, xproto.GetPropertyTypeAny, 0, 256).Reply()
+		if err != nil {
+			return "", err
+		}
+
+		if len(reply.Value) > 0 {
+			return string(reply.Value), nil
+		}
+	}
+
+	return "", nil
+}
+
+// windowRect gets the dimensions for a window.
+func windowRect(w xproto.Window) (image.Rectangle, error) {
+	c, _, err := connect()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+
+	g, err := xproto.GetGeometry(c, xproto.Drawable(w)).Reply()
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("Error getting window dimensions: %s", err)
+	}
+
+	return image.Rect(0, 0, int(g.Width), int(g.Height)), nil
+}
@@ -12,6 +12,7 @@ function clear(err = '') {
     $('.self').css('opacity', 0);
     $('.regis').css('opacity', 0);
     $('.regis-bottom').css('opacity', 0);
+    $('.goals').css('opacity', 0);
     $('.error').css('opacity', '.9');
     $('.error').html(err);
 
@@ -46,6 +47,30 @@ function error(err) {
     return shake();
 }
 
+// goals draws the remaining capacity of every goal zone of a team.
+function goals(team, zones) {
+    var html = '';
+
+    for (var i in zones) {
+        var g = zones[i];
+
+        var remaining = '&infin;';
+        var width = 100;
+        if (g.capacity > 0) {
+            remaining = g.remaining;
+            width = Math.round(100 * g.remaining / g.capacity);
+        }
+
+        html += `<div class="goal${g.destroyed ? ' goal-destroyed' : ''}">`;
+        html += `<span>${g.lane[0].toUpperCase()}${g.tier}</span>`;
+        html += `<div class="goal-bar"><div class="goal-bar-${team}" style="width: ${width}%"></div></div>`;
+        html += `<b>${g.destroyed ? 0 : remaining}</b>`;
+        html += `</div>`;
+    }
+
+    $(`.goals-${team}`).html(html);
+}
+
 function http() {
     $.ajax({
         type: 'GET',
@@ -105,6 +130,7 @@ function success(data) {
         $('.self').css('opacity', 1);
         $('.regis').css('opacity', 1);
         $('.regis-bottom').css('opacity', 1);
+        $('.goals').css('opacity', 1);
 
         var p = '';
         var o = '';
@@ -128,6 +154,11 @@ function success(data) {
         "orange": ["orange", "purple", "none"],
     }
 
+    if (data.goals) {
+        goals('purple', data.goals.purple);
+        goals('orange', data.goals.orange);
+    }
+
     for (var i in data.regis) {
         $(`.regis-${parseInt(i)+1} .regis-circle-${cache[data.regis[i]][0]}`).css('opacity', 1);
         $(`.regis-${parseInt(i)+1} .regis-circle-${cache[data.regis[i]][1]}`).css('opacity', 0);
@@ -161,6 +192,10 @@ function websocket() {
         success(JSON.parse(event.data));
     };
     socket.onerror = error;
+    // The server keeps the connection open and pushes updates, reconnect when it closes.
+    socket.onclose = function() {
+        setTimeout(websocket, 1000);
+    };
 }
 
 $(document).ready(() => {
@@ -201,5 +236,5 @@ $(document).ready(() => {
 
     console.info(`[UniteHUD] creating websocket connection to ${urlWS} (add "?http" to connect to the http endpoint)`);
 
-    setInterval(websocket, 1000);
-});
\ No newline at end of file
+    websocket();
+});
\ No newline at end of file
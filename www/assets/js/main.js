@@ -181,6 +181,10 @@ function websocket() {
         success(JSON.parse(event.data));
     };
     socket.onerror = error;
+    // The server keeps the connection open and pushes updates, reconnect when it closes.
+    socket.onclose = function() {
+        setTimeout(websocket, 1000);
+    };
 }
 
 $(document).ready(() => {
@@ -202,7 +206,7 @@ $(document).ready(() => {
 
     console.info(`[UniteHUD] creating websocket connection to ${urlWS} (add "?http" to connect to the http endpoint)`);
 
-    setInterval(websocket, 1000);
+    websocket();
 });
 
 function sendtestdata() {
@@ -226,4 +230,4 @@ function sendtestdata() {
             ],
         });
     }, 1000);
-}
\ No newline at end of file
+}
\ No newline at end of file